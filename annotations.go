@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/semver"
+)
+
+// annotationPrefix starts a gobump annotation in a comment on a require line,
+// or in the comment lines right above it:
+//
+//	github.com/sirupsen/logrus v1.9.3 // gobump:max v1.9 v1.10 breaks our formatter
+//	// gobump:hold waiting for upstream fix
+//	github.com/onsi/gomega v1.33.0
+//
+// Supported annotations are hold, max, constraint and ignore; the text after
+// the value is the reason. A constraint range with spaces is quoted, e.g.
+// gobump:constraint ">=v1.2.0 <v1.10.0". Several annotations are separated
+// by semicolons.
+const annotationPrefix = "gobump:"
+
+// requireAnnotations returns the annotations of a require entry, with the
+// prefix removed from the comment tokens.
+func requireAnnotations(r *modfile.Require) []modfile.Comment {
+	if r.Syntax == nil {
+		return nil
+	}
+	var result []modfile.Comment
+	for _, c := range slices.Concat(r.Syntax.Comments.Before, r.Syntax.Comments.Suffix) {
+		text := strings.TrimSpace(strings.TrimPrefix(c.Token, "//"))
+		for _, part := range strings.Split(text, ";") {
+			part = strings.TrimSpace(part)
+			if rest, ok := strings.CutPrefix(part, annotationPrefix); ok {
+				result = append(result, modfile.Comment{Start: c.Start, Token: rest})
+			}
+		}
+	}
+	return result
+}
+
+// parseAnnotations reads the gobump annotations of mod into per-module
+// settings; errors carry the go.mod line.
+func parseAnnotations(file string, mod *modfile.File) (map[string]ModuleConfig, []ConfigError) {
+	modules := map[string]ModuleConfig{}
+	var errs []ConfigError
+	for _, r := range mod.Require {
+		for _, a := range requireAnnotations(r) {
+			mc := modules[r.Mod.Path]
+			kind, rest, _ := strings.Cut(a.Token, " ")
+			value, reason, _ := strings.Cut(strings.TrimSpace(rest), " ")
+			fail := func(msg string) {
+				errs = append(errs, ConfigError{File: file, Line: a.Start.Line, Msg: fmt.Sprintf("%s: %s", r.Mod.Path, msg)})
+			}
+			switch kind {
+			case "hold":
+				mc.Hold = true
+				reason = strings.TrimSpace(rest)
+			case "max":
+				if !semver.IsValid(value) {
+					fail(fmt.Sprintf("invalid gobump:max version %q", value))
+				}
+				mc.Max = value
+			case "constraint":
+				if quoted, ok := strings.CutPrefix(strings.TrimSpace(rest), `"`); ok {
+					var closed bool
+					value, reason, closed = strings.Cut(quoted, `"`)
+					if !closed {
+						fail(fmt.Sprintf("unterminated gobump:constraint %q", rest))
+						break
+					}
+				} else if next, _, _ := strings.Cut(strings.TrimSpace(reason), " "); next != "" {
+					if _, err := parseVersionComparison(next); err == nil {
+						fail(fmt.Sprintf("gobump:constraint range %s %s must be quoted or separated by commas", value, next))
+						break
+					}
+				}
+				if _, err := parseVersionRange(value); err != nil {
+					fail(fmt.Sprintf("invalid gobump:constraint %q: %s", value, err))
+				}
+				mc.Constraint = value
+			case "ignore":
+				if !semver.IsValid(value) {
+					fail(fmt.Sprintf("invalid gobump:ignore version %q", value))
+				}
+				mc.Ignore = append(mc.Ignore, value)
+			default:
+				fail(fmt.Sprintf("unknown annotation gobump:%s", kind))
+			}
+			if reason = strings.TrimSpace(reason); reason != "" {
+				mc.Reason = reason
+			}
+			modules[r.Mod.Path] = mc
+		}
+	}
+	return modules, errs
+}
+
+// applyAnnotations merges annotations into config.Modules; a setting given in
+// go.mod replaces the one from the configuration file.
+func applyAnnotations(annotated map[string]ModuleConfig) {
+	if len(annotated) > 0 && config.Modules == nil {
+		config.Modules = map[string]ModuleConfig{}
+	}
+	for path, a := range annotated {
+		mc := config.Modules[path]
+		mc.Hold = mc.Hold || a.Hold
+		if a.Max != "" {
+			mc.Max = a.Max
+		}
+		if a.Constraint != "" {
+			mc.Constraint = a.Constraint
+		}
+		mc.Ignore = append(mc.Ignore, a.Ignore...)
+		if a.Reason != "" {
+			mc.Reason = a.Reason
+		}
+		config.Modules[path] = mc
+	}
+}
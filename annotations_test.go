@@ -0,0 +1,91 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+func TestParseAnnotations(t *testing.T) {
+	mod, err := modfile.Parse("go.mod", []byte(`module example.com/main
+
+go 1.22
+
+require (
+	github.com/sirupsen/logrus v1.9.3 // gobump:max v1.9 v1.10 breaks our formatter
+	// gobump:hold waiting for upstream fix
+	github.com/onsi/gomega v1.33.0
+	example.com/plain v1.0.0 // just a comment
+	example.com/multi v1.0.0 // gobump:ignore v1.1.0; gobump:constraint <v2.0.0,>=v1.0.0 major bump pending
+	example.com/indirect v1.0.0 // indirect; gobump:max v1.2
+	example.com/range v1.2.0 // gobump:constraint ">=v1.2.0 <v1.10.0" v1.10 drops Go 1.22
+)
+`), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, errs := parseAnnotations("go.mod", mod)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	want := map[string]ModuleConfig{
+		"github.com/sirupsen/logrus": {Max: "v1.9", Reason: "v1.10 breaks our formatter"},
+		"github.com/onsi/gomega":     {Hold: true, Reason: "waiting for upstream fix"},
+		"example.com/multi":          {Ignore: []string{"v1.1.0"}, Constraint: "<v2.0.0,>=v1.0.0", Reason: "major bump pending"},
+		"example.com/indirect":       {Max: "v1.2"},
+		"example.com/range":          {Constraint: ">=v1.2.0 <v1.10.0", Reason: "v1.10 drops Go 1.22"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("parseAnnotations mismatch (-want +got):\n%s", diff)
+	}
+	if !mod.Require[4].Indirect {
+		t.Errorf("annotation must not hide the indirect comment")
+	}
+}
+
+func TestParseAnnotationsErrors(t *testing.T) {
+	mod, err := modfile.Parse("go.mod", []byte(`module example.com/main
+
+require (
+	example.com/a v1.0.0 // gobump:max 1.9
+	example.com/b v1.0.0 // gobump:pin v1.0.0
+	example.com/c v1.2.0 // gobump:constraint >=v1.2.0 <v1.10.0
+	example.com/d v1.2.0 // gobump:constraint ">=v1.2.0 <v1.10.0
+)
+`), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, errs := parseAnnotations("go.mod", mod)
+	want := []ConfigError{
+		{File: "go.mod", Line: 4, Msg: `example.com/a: invalid gobump:max version "1.9"`},
+		{File: "go.mod", Line: 5, Msg: "example.com/b: unknown annotation gobump:pin"},
+		{File: "go.mod", Line: 6, Msg: "example.com/c: gobump:constraint range >=v1.2.0 <v1.10.0 must be quoted or separated by commas"},
+		{File: "go.mod", Line: 7, Msg: `example.com/d: unterminated gobump:constraint "\">=v1.2.0 <v1.10.0"`},
+	}
+	if diff := cmp.Diff(want, errs); diff != "" {
+		t.Errorf("errors mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestApplyAnnotations(t *testing.T) {
+	config = &AppConfig{Modules: map[string]ModuleConfig{
+		"example.com/a": {Update: updatePatch, Max: "v1.5", Reason: "from config"},
+	}}
+	applyAnnotations(map[string]ModuleConfig{
+		"example.com/a": {Max: "v1.4"},
+		"example.com/b": {Hold: true, Reason: "flaky"},
+	})
+	want := map[string]ModuleConfig{
+		"example.com/a": {Update: updatePatch, Max: "v1.4", Reason: "from config"},
+		"example.com/b": {Hold: true, Reason: "flaky"},
+	}
+	if diff := cmp.Diff(want, config.Modules); diff != "" {
+		t.Errorf("Modules mismatch (-want +got):\n%s", diff)
+	}
+	if got := excludedBy(module.Version{Path: "example.com/b", Version: "v1.0.0"}); got != holdAnnotation {
+		t.Errorf("excludedBy = %q, want %q", got, holdAnnotation)
+	}
+}
@@ -0,0 +1,433 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// moduleAPI maps the import paths of the packages of a module to their
+// exported declarations. Declarations are keyed by name, methods, struct
+// fields and interface methods by Type.Name; the value describes the
+// declaration, two equal descriptions are compatible.
+type moduleAPI map[string]map[string]string
+
+// apiChange is an incompatible change of the exported API of a package.
+type apiChange struct {
+	Package string
+	Change  string
+}
+
+func (c apiChange) String() string {
+	return c.Package + ": " + c.Change
+}
+
+// apiPackageDir reports whether a directory of a module zip holds a package
+// other modules can import; internal packages are left out.
+func apiPackageDir(dir string) bool {
+	for _, elem := range strings.Split(dir, "/") {
+		if elem == "internal" || elem == "testdata" || elem == "vendor" ||
+			strings.HasPrefix(elem, "_") || strings.HasPrefix(elem, ".") {
+			return false
+		}
+	}
+	return true
+}
+
+// readModuleAPI extracts the exported API of every package in a module zip,
+// reading the files the current GOOS and GOARCH build.
+func readModuleAPI(modulePath, version string, zipData []byte) (moduleAPI, error) {
+	zr, err := zip.NewReader(bytes.NewReader(zipData), int64(len(zipData)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open module zip: %w", err)
+	}
+	prefix := modulePath + "@" + version + "/"
+	sources := map[string][]byte{}
+	dirs := map[string][]string{}
+	for _, f := range zr.File {
+		name, ok := strings.CutPrefix(f.Name, prefix)
+		if !ok || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		dir, file := path.Split(name)
+		dir = strings.TrimSuffix(dir, "/")
+		if !apiPackageDir(dir) {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
+		}
+		src, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
+		}
+		sources[filepath.Join(dir, file)] = src
+		dirs[dir] = append(dirs[dir], file)
+	}
+
+	ctxt := build.Default
+	ctxt.OpenFile = func(name string) (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(sources[name])), nil
+	}
+	api := moduleAPI{}
+	fset := token.NewFileSet()
+	for dir, files := range dirs {
+		decls := map[string]string{}
+		command := false
+		for _, file := range files {
+			if ok, err := ctxt.MatchFile(dir, file); err != nil || !ok {
+				continue
+			}
+			f, err := parser.ParseFile(fset, path.Join(dir, file), sources[filepath.Join(dir, file)], parser.SkipObjectResolution)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+			}
+			if f.Name.Name == "main" {
+				// Commands cannot be imported, they have no API.
+				command = true
+				break
+			}
+			addExportedDecls(decls, f)
+		}
+		if len(decls) > 0 && !command {
+			api[path.Join(modulePath, dir)] = decls
+		}
+	}
+	return api, nil
+}
+
+// fieldTypes lists the types of a parameter or result list, without names.
+func fieldTypes(fields *ast.FieldList) []string {
+	if fields == nil {
+		return nil
+	}
+	var result []string
+	for _, f := range fields.List {
+		for range max(1, len(f.Names)) {
+			result = append(result, types.ExprString(f.Type))
+		}
+	}
+	return result
+}
+
+// signature formats a function type without parameter names, e.g. (string, int) (bool, error).
+func signature(ft *ast.FuncType) string {
+	s := "(" + strings.Join(fieldTypes(ft.Params), ", ") + ")"
+	switch results := fieldTypes(ft.Results); len(results) {
+	case 0:
+	case 1:
+		s += " " + results[0]
+	default:
+		s += " (" + strings.Join(results, ", ") + ")"
+	}
+	return s
+}
+
+// typeParams formats a type parameter list, e.g. [K comparable, V any].
+func typeParams(fields *ast.FieldList) string {
+	if fields == nil {
+		return ""
+	}
+	var params []string
+	for _, f := range fields.List {
+		for _, n := range f.Names {
+			params = append(params, n.Name+" "+types.ExprString(f.Type))
+		}
+	}
+	return "[" + strings.Join(params, ", ") + "]"
+}
+
+// embeddedName returns the field name of an embedded field type.
+func embeddedName(t ast.Expr) string {
+	switch e := t.(type) {
+	case *ast.StarExpr:
+		return embeddedName(e.X)
+	case *ast.SelectorExpr:
+		return e.Sel.Name
+	case *ast.IndexExpr:
+		return embeddedName(e.X)
+	case *ast.IndexListExpr:
+		return embeddedName(e.X)
+	case *ast.Ident:
+		return e.Name
+	}
+	return ""
+}
+
+// addExportedDecls adds the exported declarations of f to decls.
+func addExportedDecls(decls map[string]string, f *ast.File) {
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if !d.Name.IsExported() {
+				continue
+			}
+			if d.Recv == nil {
+				decls[d.Name.Name] = "func " + d.Name.Name + typeParams(d.Type.TypeParams) + signature(d.Type)
+				continue
+			}
+			recv := d.Recv.List[0].Type
+			ptr := ""
+			if star, ok := recv.(*ast.StarExpr); ok {
+				ptr, recv = "*", star.X
+			}
+			typeName := embeddedName(recv)
+			if !ast.IsExported(typeName) {
+				continue
+			}
+			decls[typeName+"."+d.Name.Name] = fmt.Sprintf("func (%s%s) %s%s", ptr, typeName, d.Name.Name, signature(d.Type))
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					if s.Name.IsExported() {
+						addTypeDecl(decls, s)
+					}
+				case *ast.ValueSpec:
+					for i, n := range s.Names {
+						if !n.IsExported() {
+							continue
+						}
+						desc := d.Tok.String() + " " + n.Name
+						if s.Type != nil {
+							desc += " " + types.ExprString(s.Type)
+						} else if d.Tok == token.VAR && len(s.Values) > 0 {
+							desc += " " + initializerType(s.Values[min(i, len(s.Values)-1)])
+						}
+						decls[n.Name] = desc
+					}
+				}
+			}
+		}
+	}
+}
+
+// initializerType describes the type of a var declared without one by its
+// initializer: the type of a literal, else the initializer with the arguments
+// of calls left out, so that changing only a value stays compatible.
+func initializerType(e ast.Expr) string {
+	switch e := e.(type) {
+	case *ast.BasicLit:
+		switch e.Kind {
+		case token.INT:
+			return "int"
+		case token.FLOAT:
+			return "float64"
+		case token.IMAG:
+			return "complex128"
+		case token.CHAR:
+			return "rune"
+		}
+		return "string"
+	case *ast.CompositeLit:
+		return types.ExprString(e.Type)
+	case *ast.UnaryExpr:
+		if lit, ok := e.X.(*ast.CompositeLit); ok && e.Op == token.AND {
+			return "*" + types.ExprString(lit.Type)
+		}
+	case *ast.FuncLit:
+		return "func" + signature(e.Type)
+	case *ast.CallExpr:
+		return "= " + types.ExprString(e.Fun) + "(...)"
+	}
+	return "= " + types.ExprString(e)
+}
+
+// addTypeDecl adds a type with its exported struct fields or interface methods.
+func addTypeDecl(decls map[string]string, s *ast.TypeSpec) {
+	name := s.Name.Name
+	desc := "type " + name + typeParams(s.TypeParams) + " "
+	if s.Assign.IsValid() {
+		desc += "= "
+	}
+	switch t := s.Type.(type) {
+	case *ast.StructType:
+		desc += "struct"
+		for _, f := range t.Fields.List {
+			names := make([]string, 0, len(f.Names))
+			for _, n := range f.Names {
+				names = append(names, n.Name)
+			}
+			if len(f.Names) == 0 {
+				names = append(names, embeddedName(f.Type))
+			}
+			for _, n := range names {
+				if ast.IsExported(n) {
+					decls[name+"."+n] = "field " + name + "." + n + " " + types.ExprString(f.Type)
+				}
+			}
+		}
+	case *ast.InterfaceType:
+		var embedded []string
+		for _, m := range t.Methods.List {
+			if len(m.Names) == 0 {
+				embedded = append(embedded, types.ExprString(m.Type))
+				continue
+			}
+			ft, ok := m.Type.(*ast.FuncType)
+			if !ok {
+				continue
+			}
+			for _, n := range m.Names {
+				if n.IsExported() {
+					decls[name+"."+n.Name] = "method " + name + "." + n.Name + signature(ft)
+				} else {
+					// Other packages cannot implement the interface, adding
+					// methods to it is compatible.
+					embedded = append(embedded, "unexported methods")
+				}
+			}
+		}
+		slices.Sort(embedded)
+		desc += "interface{" + strings.Join(slices.Compact(embedded), "; ") + "}"
+	default:
+		desc += types.ExprString(s.Type)
+	}
+	decls[name] = desc
+}
+
+// diffModuleAPI returns the incompatible changes between two versions of a
+// module: removed packages and declarations, changed declarations and
+// methods added to interfaces other packages can implement.
+func diffModuleAPI(before, after moduleAPI) []apiChange {
+	var changes []apiChange
+	for _, pkg := range sortedKeys(before) {
+		afterDecls, ok := after[pkg]
+		if !ok {
+			changes = append(changes, apiChange{Package: pkg, Change: "package removed"})
+			continue
+		}
+		beforeDecls := before[pkg]
+		for _, name := range sortedKeys(beforeDecls) {
+			b := beforeDecls[name]
+			a, ok := afterDecls[name]
+			switch {
+			case !ok:
+				changes = append(changes, apiChange{Package: pkg, Change: "removed " + b})
+			case a != b:
+				changes = append(changes, apiChange{Package: pkg, Change: "changed " + b + " => " + a})
+			}
+		}
+		for _, name := range sortedKeys(afterDecls) {
+			a := afterDecls[name]
+			iface, _, isMethod := strings.Cut(name, ".")
+			if _, ok := beforeDecls[name]; ok || !isMethod || !strings.HasPrefix(a, "method ") {
+				continue
+			}
+			if strings.Contains(afterDecls[iface], "unexported methods") {
+				continue
+			}
+			changes = append(changes, apiChange{Package: pkg, Change: "added " + a})
+		}
+	}
+	return changes
+}
+
+// apiCache keeps the API of module versions and the changes between them, so
+// that the summary and commit messages do not download zips again.
+var apiCache = struct {
+	sync.Mutex
+	apis    map[string]moduleAPI
+	changes map[string][]apiChange
+}{apis: map[string]moduleAPI{}, changes: map[string][]apiChange{}}
+
+// fetchModuleAPI downloads the zip of a module version and reads its API.
+func fetchModuleAPI(proxy *GoProxy, modulePath, version string) (moduleAPI, error) {
+	key := modulePath + "@" + version
+	apiCache.Lock()
+	api, ok := apiCache.apis[key]
+	apiCache.Unlock()
+	if ok {
+		return api, nil
+	}
+	data, err := proxy.FetchZip(modulePath, version)
+	if err != nil {
+		return nil, err
+	}
+	if api, err = readModuleAPI(modulePath, version, data); err != nil {
+		return nil, err
+	}
+	apiCache.Lock()
+	apiCache.apis[key] = api
+	apiCache.Unlock()
+	return api, nil
+}
+
+// moduleAPIChanges returns the incompatible API changes between two versions of a module.
+func moduleAPIChanges(proxy *GoProxy, modulePath, before, after string) ([]apiChange, error) {
+	if changes, ok := cachedAPIChanges(modulePath, before, after); ok {
+		return changes, nil
+	}
+	beforeAPI, err := fetchModuleAPI(proxy, modulePath, before)
+	if err != nil {
+		return nil, err
+	}
+	afterAPI, err := fetchModuleAPI(proxy, modulePath, after)
+	if err != nil {
+		return nil, err
+	}
+	changes := diffModuleAPI(beforeAPI, afterAPI)
+	apiCache.Lock()
+	apiCache.changes[modulePath+"@"+before+".."+after] = changes
+	apiCache.Unlock()
+	return changes, nil
+}
+
+// cachedAPIChanges returns the changes moduleAPIChanges computed before, ok is
+// false when there are none (-apidiff not given or the lookup failed).
+func cachedAPIChanges(modulePath, before, after string) ([]apiChange, bool) {
+	apiCache.Lock()
+	defer apiCache.Unlock()
+	changes, ok := apiCache.changes[modulePath+"@"+before+".."+after]
+	return changes, ok
+}
+
+// apiChangeList formats the cached API changes of a bump, or nil.
+func apiChangeList(modulePath, before, after string) []string {
+	changes, _ := cachedAPIChanges(modulePath, before, after)
+	var result []string
+	for _, c := range changes {
+		result = append(result, c.String())
+	}
+	return result
+}
+
+var importedPackages = sync.OnceValues(func() (map[string]bool, error) {
+	buf, err := cmdOutput(config.GoBinary, "list", "-test", "-f", `{{join .Imports "\n"}}`, "./...")
+	if err != nil {
+		return nil, fmt.Errorf("go list: %w", err)
+	}
+	imported := map[string]bool{}
+	for _, line := range strings.Fields(string(buf)) {
+		imported[line] = true
+	}
+	return imported, nil
+})
+
+// importedAPIChanges returns the changes in packages the main module (or its tests) imports.
+func importedAPIChanges(changes []apiChange) ([]apiChange, error) {
+	imported, err := importedPackages()
+	if err != nil {
+		return nil, err
+	}
+	var result []apiChange
+	for _, c := range changes {
+		if imported[c.Package] {
+			result = append(result, c)
+		}
+	}
+	return result, nil
+}
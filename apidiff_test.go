@@ -0,0 +1,192 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func testModuleZip(t *testing.T, prefix string, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(prefix + "/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+var apiBefore = map[string]string{
+	"go.mod": "module example.com/lib\n",
+	"lib.go": `package lib
+
+import "errors"
+
+const Version = "1"
+
+var ErrClosed = errors.New("closed")
+
+var DefaultTimeout = 30
+
+var DefaultClient = &Client{Name: "default"}
+
+type Client struct {
+	Name    string
+	Timeout int
+	secret  string
+}
+
+func (c *Client) Do(path string, retries int) error { return nil }
+
+func (c *Client) Close() {}
+
+type Handler interface {
+	Handle(req string) error
+}
+
+type Sealed interface {
+	Kind() string
+	sealed()
+}
+
+func New(name string) *Client { return nil }
+
+func Parse(a, b string) (int, error) { return 0, nil }
+`,
+	"lib_test.go":           "package lib\n\nfunc TestOnly() {}\n",
+	"lib_windows.go":        "package lib\n\nfunc WindowsOnly() {}\n",
+	"internal/x/x.go":       "package x\n\nfunc Hidden() {}\n",
+	"extra/extra.go":        "package extra\n\nfunc Extra() {}\n",
+	"cmd/tool/main.go":      "package main\n\nfunc Tool() {}\n",
+	"cmd/mixed/a.go":        "package mixed\n\nfunc A() {}\n",
+	"cmd/mixed/b.go":        "package main\n\nfunc B() {}\n",
+	"testdata/data/data.go": "package data\n\nfunc Data() {}\n",
+}
+
+var apiAfter = map[string]string{
+	"go.mod": "module example.com/lib\n",
+	"lib.go": `package lib
+
+import "errors"
+
+const Version = "2"
+
+var ErrClosed = errors.New("client closed")
+
+var DefaultTimeout = 30.5
+
+var DefaultClient = &Client{Name: "client"}
+
+type Client struct {
+	Name    string
+	Timeout int64
+	Extra   bool
+}
+
+func (c *Client) Do(p string, n int) error { return nil }
+
+type Handler interface {
+	Handle(req string) error
+	Close() error
+}
+
+type Sealed interface {
+	Kind() string
+	Name() string
+	sealed()
+}
+
+func New(name string, opts ...string) *Client { return nil }
+
+func Parse(x, y string) (int, error) { return 0, nil }
+
+func Added() {}
+`,
+	"internal/x/x.go": "package x\n",
+}
+
+func TestDiffModuleAPI(t *testing.T) {
+	before, err := readModuleAPI("example.com/lib", "v1.0.0", testModuleZip(t, "example.com/lib@v1.0.0", apiBefore))
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := readModuleAPI("example.com/lib", "v1.1.0", testModuleZip(t, "example.com/lib@v1.1.0", apiAfter))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := before["example.com/lib"]["WindowsOnly"]; ok && runtime.GOOS != "windows" {
+		t.Error("file for another GOOS was read")
+	}
+
+	var got []string
+	for _, c := range diffModuleAPI(before, after) {
+		got = append(got, c.String())
+	}
+	want := []string{
+		"example.com/lib: removed func (*Client) Close()",
+		"example.com/lib: changed field Client.Timeout int => field Client.Timeout int64",
+		"example.com/lib: changed var DefaultTimeout int => var DefaultTimeout float64",
+		"example.com/lib: changed func New(string) *Client => func New(string, ...string) *Client",
+		"example.com/lib: added method Handler.Close() error",
+		"example.com/lib/extra: package removed",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("diffModuleAPI (-want +got):\n%s", diff)
+	}
+}
+
+func TestModuleAPIChanges(t *testing.T) {
+	zips := map[string][]byte{
+		"/example.com/lib/@v/v1.0.0.zip": testModuleZip(t, "example.com/lib@v1.0.0", apiBefore),
+		"/example.com/lib/@v/v1.1.0.zip": testModuleZip(t, "example.com/lib@v1.1.0", apiAfter),
+	}
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		data, ok := zips[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	}))
+	defer server.Close()
+	proxy := NewGoProxy(server.URL)
+
+	changes, err := moduleAPIChanges(proxy, "example.com/lib", "v1.0.0", "v1.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 6 {
+		t.Errorf("got %d changes, want 6", len(changes))
+	}
+	if _, err := moduleAPIChanges(proxy, "example.com/lib", "v1.0.0", "v1.1.0"); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 2 {
+		t.Errorf("got %d proxy requests, want 2", requests)
+	}
+	if got := apiChangeList("example.com/lib", "v1.0.0", "v1.1.0"); len(got) != 6 {
+		t.Errorf("apiChangeList = %v", got)
+	}
+	if got := apiChangeList("example.com/lib", "v1.0.0", "v1.2.0"); got != nil {
+		t.Errorf("apiChangeList without diff = %v", got)
+	}
+	if _, err := moduleAPIChanges(proxy, "example.com/lib", "v1.0.0", "v1.2.0"); err == nil {
+		t.Error("expected an error for a missing zip")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
 )
 
@@ -31,26 +32,81 @@ func (i *stringSlice) Set(value string) error {
 	return nil
 }
 
+const (
+	// commandOutdated prints a read-only report of newer dependency versions.
+	commandOutdated = "outdated"
+	// commandMatrix prints the highest usable version of each dependency per Go release.
+	commandMatrix = "matrix"
+	// commandExplain explains why newer versions of one module cannot be adopted.
+	commandExplain = "explain"
+	// commandConfig validates the configuration file ("config validate").
+	commandConfig = "config"
+)
+
+// subcommands are the commands accepted as the first positional argument;
+// without one, gobump bumps dependencies.
+var subcommands = []string{commandOutdated, commandMatrix, commandExplain, commandConfig}
+
 // AppConfig holds the application configuration
 type AppConfig struct {
-	Version       bool
-	DryRun        bool
-	Verbose       bool
-	Format        string
-	GoModSrc      string
-	GoModDst      string
-	Retries       int
-	Commands      stringSlice
-	GoBinary      string
-	Changelog     bool
-	ChangelogDest string
-	Dependencies  []string
-	Exclude       commaSeparatedStringSlice
-	NoGit         bool
-	GitUserName   string
-	GitUserEmail  string
-	ModuleProxy   string
-	FailOnError   bool
+	Command            string
+	Version            bool
+	DryRun             bool
+	Verbose            bool
+	Format             string
+	GoModSrc           string
+	GoModDst           string
+	Retries            int
+	Commands           stringSlice
+	Baseline           string
+	ExecRetries        int
+	GoBinary           string
+	Changelog          bool
+	ChangelogDest      string
+	Dependencies       []string
+	Exclude            commaSeparatedStringSlice
+	Hold               commaSeparatedStringSlice
+	NoGit              bool
+	GitUserName        string
+	GitUserEmail       string
+	ModuleProxy        string
+	FailOnError        bool
+	GraphOrder         bool
+	Parallel           int
+	GoPolicy           string
+	ToolchainPolicy    string
+	TargetGo           string
+	PinToolchain       bool
+	JSON               string
+	Security           bool
+	VulnDB             string
+	Reachability       bool
+	ReachableOnly      bool
+	APIDiff            bool
+	RejectIncompatible bool
+	LicenseCheck       string
+	LicenseAllow       commaSeparatedStringSlice
+	CgoCheck           string
+	SizePackages       commaSeparatedStringSlice
+	SizeLimit          sizeLimit
+	TestPackages       commaSeparatedStringSlice
+	BenchPackages      commaSeparatedStringSlice
+	Bench              string
+	BenchCount         int
+	MaxBenchRegression float64
+	MaxNewModules      int
+	DenyModules        commaSeparatedStringSlice
+	DenySelectors      selectorList
+	ConfigFile         string
+	ConfigErrors       []ConfigError
+	Update             string
+	CommitPrefix       string
+	Modules            map[string]ModuleConfig
+	Groups             map[string][]string
+
+	IncludeSelectors selectorList // positional arguments
+	ExcludeSelectors selectorList
+	HoldSelectors    selectorList
 }
 
 var config *AppConfig
@@ -78,11 +134,20 @@ func InitConfig() {
 
 	var commands stringSlice
 	var exclude commaSeparatedStringSlice
+	var hold commaSeparatedStringSlice
+	var licenseAllow commaSeparatedStringSlice
+	var denyModules commaSeparatedStringSlice
+	var sizePackages commaSeparatedStringSlice
+	var testPackages commaSeparatedStringSlice
+	var benchPackages commaSeparatedStringSlice
 	flag.BoolVar(&config.Version, "version", false, "print Go binary debug info")
 	flag.BoolVar(&config.DryRun, "dry-run", false, "revert to original go.mod after running")
 	flag.BoolVar(&config.Verbose, "verbose", defaultVerbose, "echo go get and -exec command output (markdown: inside detail blocks); does not log git operations in git mode")
 	flag.Var(&commands, "exec", "exec command for each individual bump, can be used multiple times")
-	flag.Var(&exclude, "exclude", "comma-separated list of modules to exclude from update")
+	flag.Var(&exclude, "exclude", "comma-separated list of module selectors to exclude from update (path, glob, path/... prefix, !negation, @<version constraint)")
+	flag.Var(&hold, "hold", "comma-separated list of module selectors whose matching versions are never adopted, e.g. example.com/mod@>=v1.5.0")
+	flag.IntVar(&config.ExecRetries, "exec-retries", 0, "re-run a failing -exec command, or only the failing -test-package tests, up to N times before rejecting a candidate; checks passing on a re-run are listed as flaky in the summary")
+	flag.StringVar(&config.Baseline, "baseline", baselineAbort, "run the -exec commands once before any bump: abort (stop when one fails), tolerate (do not reject candidates for commands that already fail in the same way) or off")
 	flag.StringVar(&config.Format, "format", defaultFormat, "output format (console, markdown, none)")
 	flag.StringVar(&config.GoModSrc, "src-go-mod", "go.mod", "path to go.mod source file (default: go.mod)")
 	flag.StringVar(&config.GoModDst, "dst-go-mod", "go.mod", "path to go.mod destination file (default: go.mod)")
@@ -94,9 +159,57 @@ func InitConfig() {
 	flag.StringVar(&config.GitUserEmail, "user-email", "schutzbot@gmail.com", "git user.email for per-dependency commits (local repo config)")
 	flag.StringVar(&config.ModuleProxy, "proxy", "", "module proxy base URL (default: first usable $GOPROXY entry, else https://proxy.golang.org)")
 	flag.BoolVar(&config.FailOnError, "fail-on-error", false, "exit with status 1 if any non-excluded module failed to update")
+	flag.BoolVar(&config.GraphOrder, "graph-order", false, "process direct dependencies in go mod graph order, so modules are bumped before the modules that require them (order is printed with -verbose)")
+	flag.IntVar(&config.Parallel, "parallel", 1, "evaluate candidate upgrades of up to N modules at the same time in isolated git worktrees (temporary copies with -no-git), then apply the winners sequentially and run -exec once more as confirmation")
+	flag.StringVar(&config.GoPolicy, "go-policy", policyKeep, "what a bump may do to the go directive: keep (no change; 1.22 and 1.22.0 are equal) or patch (patch releases within the same Go version, e.g. 1.22.0 => 1.22.5)")
+	flag.StringVar(&config.ToolchainPolicy, "toolchain-policy", policyKeep, "what a bump may do to the toolchain directive: keep (no change), patch (add or raise within the go directive's Go version), absent (must not be present) or any")
+	flag.StringVar(&config.TargetGo, "target-go", "", "raise the go directive to this version first (committed separately in git mode), then bump dependencies with it as the ceiling")
+	flag.BoolVar(&config.PinToolchain, "pin-toolchain", true, "set GOTOOLCHAIN for every subprocess to the toolchain pinned by go.mod (toolchain directive, else the go directive) unless GOTOOLCHAIN is already set")
+	flag.StringVar(&config.JSON, "json", "", "also write the report of the outdated, matrix and explain subcommands as JSON to this file (\"-\" for stdout)")
+	flag.BoolVar(&config.Security, "security", false, "only bump direct and indirect requirements with known vulnerabilities in -vulndb, each to the smallest version without them")
+	flag.BoolVar(&config.APIDiff, "apidiff", false, "compare the exported API of the module zips before and after each bump and report incompatible changes in the summary and commit messages")
+	flag.BoolVar(&config.RejectIncompatible, "reject-incompatible", false, "reject versions with incompatible API changes in packages the main module imports (implies -apidiff)")
+	flag.StringVar(&config.LicenseCheck, "license-check", checkOff, "compare the licenses of the module zips before and after each bump and of modules it adds: off, warn (report license changes and licenses outside -license-allow) or block (reject such versions)")
+	flag.Var(&licenseAllow, "license-allow", "comma-separated list of SPDX license identifiers allowed with -license-check, e.g. MIT,Apache-2.0,BSD-3-Clause (default: any)")
+	flag.StringVar(&config.CgoCheck, "cgo-check", checkOff, "look for packages the main module imports that need cgo after a bump but did not before: off, warn (report them) or block (reject such versions), for CGO_ENABLED=0 builds")
+	flag.Var(&sizePackages, "size-package", "comma-separated list of main packages to build before the run and after each candidate, e.g. ./cmd/app; the binary size change is shown in the summary")
+	flag.Var(&config.SizeLimit, "max-size-growth", "reject versions growing a -size-package binary by more than this, a size such as 512K or 2M, or a percentage such as 5% (default: no limit)")
+	flag.Var(&testPackages, "test-package", "comma-separated list of packages to test with go test -json after the -exec commands pass, e.g. ./...; candidates with failing tests are rejected and the tests listed in the summary")
+	flag.Var(&benchPackages, "bench-package", "comma-separated list of packages whose benchmarks run -bench-count times before the run and after each candidate, e.g. ./internal/parser; the comparison is shown in the summary")
+	flag.StringVar(&config.Bench, "bench", ".", "regular expression selecting the -bench-package benchmarks, as for go test -bench")
+	flag.IntVar(&config.BenchCount, "bench-count", 6, "how many times to run each benchmark before and after a candidate; fewer than 5 runs rarely give a significant difference")
+	flag.Float64Var(&config.MaxBenchRegression, "max-bench-regression", 0, "reject versions making a -bench-package benchmark significantly slower by more than this percentage (default: report only)")
+	flag.IntVar(&config.MaxNewModules, "max-new-modules", -1, "reject versions that add more than N modules to the module graph (default: no limit)")
+	flag.Var(&denyModules, "deny-module", "comma-separated list of module selectors that must never enter the module graph; versions adding a matching module are rejected")
+	flag.BoolVar(&config.Reachability, "reachability", false, "mark advisories from -vulndb as reachable or unreachable from the call graph of the main module; -security bumps modules with reachable ones first")
+	flag.BoolVar(&config.ReachableOnly, "reachable-only", false, "with -security, only fix advisories reachable from the main module (implies -reachability)")
+	flag.StringVar(&config.VulnDB, "vulndb", "", "OSV vulnerability database as published by vuln.go.dev, a directory or zip file; fixed advisories are listed in the summary and commit messages")
+	flag.StringVar(&config.ConfigFile, "config", defaultConfigFile, "repository configuration file; flags given on the command line override its settings")
+	flag.StringVar(&config.Update, "update", updateMajor, "highest update level to adopt: patch (same minor version), minor (same major version) or major (any newer version of the module path)")
+	flag.StringVar(&config.CommitPrefix, "commit-prefix", "chore(deps)", "prefix of per-dependency commit messages")
 	flag.Parse()
 
 	config.Commands = commands
 	config.Dependencies = flag.Args()
+	if len(config.Dependencies) > 0 && slices.Contains(subcommands, config.Dependencies[0]) {
+		config.Command = config.Dependencies[0]
+		config.Dependencies = config.Dependencies[1:]
+	}
 	config.Exclude = exclude
+	config.Hold = hold
+	config.LicenseAllow = licenseAllow
+	config.DenyModules = denyModules
+	config.SizePackages = sizePackages
+	config.TestPackages = testPackages
+	config.BenchPackages = benchPackages
+
+	set := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	fc, errs := loadConfigFile(config.ConfigFile, set["config"])
+	config.ConfigErrors = errs
+	if fc != nil {
+		applyFileConfig(fc, set)
+	}
 }
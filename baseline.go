@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const (
+	// baselineAbort stops the run when an -exec command or a -test-package
+	// test fails before any bump.
+	baselineAbort = "abort"
+	// baselineTolerate records the failing commands and tests; candidates are
+	// not rejected for them.
+	baselineTolerate = "tolerate"
+	// baselineOff skips the baseline run.
+	baselineOff = "off"
+)
+
+var baselineModes = []string{baselineAbort, baselineTolerate, baselineOff}
+
+// validateBaseline checks the -baseline value.
+func validateBaseline() error {
+	if !slices.Contains(baselineModes, config.Baseline) {
+		return fmt.Errorf("invalid -baseline %q, expected one of %v", config.Baseline, baselineModes)
+	}
+	return nil
+}
+
+// commandFailure is how a run of an -exec command failed: its exit status
+// and its output, with durations and the workspace directory left out so that
+// runs in different workspaces compare equal.
+type commandFailure struct {
+	status int // -1 when the command could not be run
+	output string
+}
+
+var durationRe = regexp.MustCompile(`\b(\d+h)?(\d+m)?\d+(\.\d+)?(ns|µs|us|ms|s)\b`)
+
+// newCommandFailure describes a failed run of a command in ws.
+func newCommandFailure(ws *workspace, output []byte, err error) *commandFailure {
+	status := -1
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		status = exitErr.ExitCode()
+	}
+	text := string(output)
+	dir := ws.Dir
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+	if dir != "" {
+		text = strings.ReplaceAll(text, dir, ".")
+	}
+	return &commandFailure{status: status, output: durationRe.ReplaceAllString(text, "")}
+}
+
+// baselineFailures are the -exec commands that failed on the tree before any
+// bump with -baseline tolerate, with how they failed. runCommands does not
+// reject candidates for which such a command fails in the same way.
+var baselineFailures map[string]commandFailure
+
+// runBaseline runs the -exec commands once on the tree before any bump, with
+// -exec-retries, and returns how the failing ones failed.
+func runBaseline(ws *workspace) map[string]commandFailure {
+	failed := map[string]commandFailure{}
+	for _, c := range config.Commands {
+		if c == "" {
+			continue
+		}
+		ws.Out.BeginPreformatted("baseline:", c)
+		_, failure := runWithRetries(ws, c, config.ExecRetries, nil)
+		if failure != nil {
+			failed[c] = *failure
+			ws.Out.Error("command fails before any bump")
+		}
+		ws.Out.EndPreformattedCond(failure != nil)
+	}
+	return failed
+}
+
+// establishBaseline runs the -exec commands and the -test-package tests once
+// on the unmodified tree before any bump, unless -baseline is off. With abort
+// it fails when any of them fail, with tolerate it records the failures in
+// baselineFailures and baselineTestFailures. It then builds the -size-package
+// binaries and runs the -bench-package benchmarks the bumps are compared with.
+func establishBaseline(ws *workspace) error {
+	if config.Baseline != baselineOff {
+		if err := baselineChecks(ws); err != nil {
+			return err
+		}
+	}
+	var err error
+	if len(config.SizePackages) > 0 {
+		if binarySizes, err = buildSizes(ws); err != nil {
+			return fmt.Errorf("failed to build -size-package before any bump: %w", err)
+		}
+	}
+	if len(config.BenchPackages) > 0 {
+		if benchBaseline, err = runBenchmarks(ws); err != nil {
+			return fmt.Errorf("failed to run -bench-package benchmarks before any bump: %w", err)
+		}
+	}
+	return nil
+}
+
+// baselineChecks runs the -exec commands and -test-package tests for establishBaseline.
+func baselineChecks(ws *workspace) error {
+	failed := runBaseline(ws)
+	for _, c := range sortedKeys(failed) {
+		if config.Baseline == baselineAbort {
+			return fmt.Errorf("-exec command %q fails before any bump; fix the tree or use -baseline tolerate", c)
+		}
+		out.Error(fmt.Sprintf("warning: -exec command %q fails before any bump, candidates are not rejected for its failures", c))
+	}
+	baselineFailures = failed
+
+	if len(config.TestPackages) == 0 {
+		return nil
+	}
+	tests, _, err := runTestGate(ws)
+	if err != nil {
+		return fmt.Errorf("failed to run -test-package tests before any bump: %w", err)
+	}
+	if len(tests) > 0 {
+		if config.Baseline == baselineAbort {
+			return fmt.Errorf("tests fail before any bump: %s; fix the tree or use -baseline tolerate", strings.Join(tests, ", "))
+		}
+		out.Error("warning: tests fail before any bump, candidates are not rejected for them: " + strings.Join(tests, ", "))
+	}
+	baselineTestFailures = map[string]bool{}
+	for _, name := range tests {
+		baselineTestFailures[name] = true
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRunBaseline(t *testing.T) {
+	config = &AppConfig{Commands: stringSlice{"true", "", "false", "sh -c exit"}}
+	ws := &workspace{Dir: t.TempDir(), Out: &OutputNone{}}
+	want := map[string]commandFailure{"false": {status: 1}}
+	if diff := cmp.Diff(want, runBaseline(ws), cmp.AllowUnexported(commandFailure{})); diff != "" {
+		t.Errorf("runBaseline mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRunCommandsBaselineFailures(t *testing.T) {
+	dir := t.TempDir()
+	check := func(script string) {
+		t.Helper()
+		writeFiles(t, dir, map[string]string{"check.sh": script})
+	}
+	config = &AppConfig{Commands: stringSlice{"true", "sh check.sh"}}
+	ws := &workspace{Dir: dir, Out: &OutputNone{}}
+	t.Cleanup(func() { baselineFailures = nil })
+
+	check("echo FAIL example.com/app 0.12s; exit 1\n")
+	baselineFailures = nil
+	if _, ok := runCommands(ws, nil); ok {
+		t.Error("runCommands succeeded with a failing command")
+	}
+	baselineFailures = runBaseline(ws)
+	check("echo FAIL example.com/app 0.34s; exit 1\n")
+	if _, ok := runCommands(ws, nil); !ok {
+		t.Error("runCommands failed for a command failing like on the baseline")
+	}
+
+	check("echo FAIL example.com/app 0.12s; echo FAIL example.com/app/new 0.01s; exit 1\n")
+	if _, ok := runCommands(ws, nil); ok {
+		t.Error("runCommands succeeded with a new failure of a baseline command")
+	}
+	check("echo FAIL example.com/app 0.12s; exit 2\n")
+	if _, ok := runCommands(ws, nil); ok {
+		t.Error("runCommands succeeded with another exit status of a baseline command")
+	}
+
+	check("exit 0\n")
+	config.Commands = append(config.Commands, "sh -c false")
+	if _, ok := runCommands(ws, nil); ok {
+		t.Error("runCommands succeeded with a new failing command")
+	}
+}
+
+func TestProcessBaselineAbortBeforeTargetGo(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "go.mod")
+	content := "module example.com/m\n\ngo 1.22\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	original, err := parseMod(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out = &OutputNone{}
+	config = &AppConfig{
+		GoBinary: "go",
+		GoModSrc: path,
+		GoModDst: path,
+		NoGit:    true,
+		TargetGo: "1.23.0",
+		Baseline: baselineAbort,
+		Commands: stringSlice{"false"},
+	}
+	t.Cleanup(func() { baselineFailures = nil })
+
+	if _, err := process(original); err == nil {
+		t.Fatal("process succeeded with a failing baseline")
+	}
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != content {
+		t.Errorf("go.mod changed by a failed baseline:\n%s", buf)
+	}
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/mod/modfile"
+)
+
+// benchAlpha is the significance level below which a difference counts, as in benchstat.
+const benchAlpha = 0.05
+
+// validateBench checks the -bench-count and -max-bench-regression values.
+func validateBench() error {
+	if config.BenchCount < 1 {
+		return fmt.Errorf("invalid -bench-count %d, must be at least 1", config.BenchCount)
+	}
+	if config.MaxBenchRegression < 0 {
+		return fmt.Errorf("invalid -max-bench-regression %g, must not be negative", config.MaxBenchRegression)
+	}
+	return nil
+}
+
+// benchSamples maps package.Benchmark to its ns/op samples.
+type benchSamples map[string][]float64
+
+// parseBenchOutput reads the ns/op results of go test -bench output. The
+// -GOMAXPROCS suffix is removed from benchmark names.
+func parseBenchOutput(buf []byte) benchSamples {
+	samples := benchSamples{}
+	pkg := ""
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if p, ok := strings.CutPrefix(line, "pkg: "); ok {
+			pkg = strings.TrimSpace(p)
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "Benchmark") {
+			continue
+		}
+		name := fields[0]
+		if i := strings.LastIndexByte(name, '-'); i != -1 {
+			if _, err := strconv.Atoi(name[i+1:]); err == nil {
+				name = name[:i]
+			}
+		}
+		for i := 2; i+1 < len(fields); i += 2 {
+			if fields[i+1] != "ns/op" {
+				continue
+			}
+			if v, err := strconv.ParseFloat(fields[i], 64); err == nil {
+				key := name
+				if pkg != "" {
+					key = pkg + "." + name
+				}
+				samples[key] = append(samples[key], v)
+			}
+		}
+	}
+	return samples
+}
+
+func median(values []float64) float64 {
+	sorted := slices.Sorted(slices.Values(values))
+	n := len(sorted)
+	if n == 0 {
+		return 0
+	}
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// mannWhitneyP returns the two-sided p-value of the Mann-Whitney U test of
+// two samples, using the normal approximation with tie correction.
+func mannWhitneyP(a, b []float64) float64 {
+	n1, n2 := float64(len(a)), float64(len(b))
+	if n1 == 0 || n2 == 0 {
+		return 1
+	}
+	type value struct {
+		v     float64
+		first bool
+	}
+	all := make([]value, 0, len(a)+len(b))
+	for _, v := range a {
+		all = append(all, value{v, true})
+	}
+	for _, v := range b {
+		all = append(all, value{v, false})
+	}
+	slices.SortFunc(all, func(x, y value) int {
+		switch {
+		case x.v < y.v:
+			return -1
+		case x.v > y.v:
+			return 1
+		}
+		return 0
+	})
+	rankSum, ties := 0.0, 0.0
+	for i := 0; i < len(all); {
+		j := i
+		for j < len(all) && all[j].v == all[i].v {
+			j++
+		}
+		rank := float64(i+j+1) / 2 // average of ranks i+1..j
+		for k := i; k < j; k++ {
+			if all[k].first {
+				rankSum += rank
+			}
+		}
+		t := float64(j - i)
+		ties += t*t*t - t
+		i = j
+	}
+	u := rankSum - n1*(n1+1)/2
+	u = math.Min(u, n1*n2-u)
+	n := n1 + n2
+	sigma := math.Sqrt(n1 * n2 / 12 * ((n + 1) - ties/(n*(n-1))))
+	if sigma == 0 {
+		return 1
+	}
+	z := (u - n1*n2/2 + 0.5) / sigma
+	return math.Min(1, math.Erfc(-z/math.Sqrt2))
+}
+
+// BenchComparison compares the ns/op of one benchmark before and after a bump.
+type BenchComparison struct {
+	Name       string  // package.Benchmark
+	Before     float64 // median ns/op
+	After      float64 // median ns/op
+	Delta      float64 // percent change of the median, 0 when not significant
+	P          float64 // Mann-Whitney U test p-value
+	Regression bool    // slower by more than -max-bench-regression
+}
+
+// formatNs formats a duration in nanoseconds, e.g. 1.25µs.
+func formatNs(ns float64) string {
+	for _, u := range []struct {
+		scale float64
+		unit  string
+	}{{1e9, "s"}, {1e6, "ms"}, {1e3, "µs"}} {
+		if ns >= u.scale {
+			return fmt.Sprintf("%.2f%s", ns/u.scale, u.unit)
+		}
+	}
+	return fmt.Sprintf("%.2fns", ns)
+}
+
+// DeltaString formats the change like benchstat: a percentage, or ~ when the
+// difference is not significant.
+func (c BenchComparison) DeltaString() string {
+	if c.Delta == 0 {
+		return "~"
+	}
+	return fmt.Sprintf("%+.2f%%", c.Delta)
+}
+
+func (c BenchComparison) String() string {
+	return fmt.Sprintf("%s %s => %s %s (p=%.3f)", c.Name, formatNs(c.Before), formatNs(c.After), c.DeltaString(), c.P)
+}
+
+// compareBenchmarks compares every benchmark present before and after.
+func compareBenchmarks(before, after benchSamples) []BenchComparison {
+	var result []BenchComparison
+	for _, name := range sortedKeys(before) {
+		if _, ok := after[name]; !ok {
+			continue
+		}
+		c := BenchComparison{
+			Name:   name,
+			Before: median(before[name]),
+			After:  median(after[name]),
+			P:      mannWhitneyP(before[name], after[name]),
+		}
+		if c.P < benchAlpha && c.Before > 0 {
+			c.Delta = (c.After - c.Before) * 100 / c.Before
+		}
+		c.Regression = config.MaxBenchRegression > 0 && c.Delta > config.MaxBenchRegression
+		result = append(result, c)
+	}
+	return result
+}
+
+// benchBaseline are the benchmark samples with the last good go.mod: taken
+// before the run and replaced after every accepted bump.
+var benchBaseline benchSamples
+
+// benchMu serialises benchmark runs, so -parallel workers do not skew each
+// other's timings.
+var benchMu sync.Mutex
+
+// runBenchmarks runs the -bench-package benchmarks -bench-count times in the workspace.
+func runBenchmarks(ws *workspace) (benchSamples, error) {
+	benchMu.Lock()
+	defer benchMu.Unlock()
+	args := []string{"test", "-run", "^$", "-bench", config.Bench, "-count", strconv.Itoa(config.BenchCount)}
+	buf, err := ws.output(config.GoBinary, append(args, config.BenchPackages...)...)
+	if err != nil {
+		return nil, fmt.Errorf("go test -bench: %w", err)
+	}
+	samples := parseBenchOutput(buf)
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("go test -bench %s: no benchmarks ran", config.Bench)
+	}
+	return samples, nil
+}
+
+// checkBenchmarks runs the benchmarks after upgrading to a candidate and
+// compares them to benchBaseline. It reports whether the version is
+// acceptable; versions with a regression beyond -max-bench-regression, or
+// whose benchmarks fail, are rejected and go.mod is restored to revertTo.
+func checkBenchmarks(ws *workspace, revertTo *modfile.File) ([]BenchComparison, benchSamples, bool) {
+	ws.Out.BeginPreformatted(config.GoBinary, "test", "-bench", config.Bench)
+	defer ws.Out.EndPreformatted()
+	reject := func(msg string) {
+		ws.Out.Error(msg + "; reverting go.mod")
+		if err := saveMod(ws.GoModDst, revertTo); err != nil {
+			ws.Out.Error("failed to revert go.mod:", err.Error())
+		}
+	}
+	samples, err := runBenchmarks(ws)
+	if err != nil {
+		reject(err.Error())
+		return nil, nil, false
+	}
+	comparisons := compareBenchmarks(benchBaseline, samples)
+	for _, c := range comparisons {
+		ws.Out.Println(c.String())
+	}
+	if slices.ContainsFunc(comparisons, func(c BenchComparison) bool { return c.Regression }) {
+		reject(fmt.Sprintf("benchmarks regressed by more than %g%%", config.MaxBenchRegression))
+		return comparisons, nil, false
+	}
+	return comparisons, samples, true
+}
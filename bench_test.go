@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseBenchOutput(t *testing.T) {
+	buf := []byte(`goos: linux
+goarch: amd64
+pkg: example.com/app/parser
+cpu: AMD EPYC
+BenchmarkParse-8   	  120000	      9876 ns/op	    2048 B/op	      12 allocs/op
+BenchmarkParse-8   	  120000	      9912.5 ns/op	    2048 B/op	      12 allocs/op
+BenchmarkParse/small-8 	 5000000	       240 ns/op
+BenchmarkNoNs-8     	     100	        12.0 MB/s
+PASS
+ok  	example.com/app/parser	3.201s
+pkg: example.com/app/lexer
+BenchmarkLex   	 1000000	      1050 ns/op
+PASS
+`)
+	want := benchSamples{
+		"example.com/app/parser.BenchmarkParse":       {9876, 9912.5},
+		"example.com/app/parser.BenchmarkParse/small": {240},
+		"example.com/app/lexer.BenchmarkLex":          {1050},
+	}
+	if diff := cmp.Diff(want, parseBenchOutput(buf)); diff != "" {
+		t.Errorf("parseBenchOutput mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRunBenchmarksSerialised(t *testing.T) {
+	dir := t.TempDir()
+	lock := filepath.Join(dir, "lock")
+	// go.sh fails when another instance is running.
+	writeFiles(t, dir, map[string]string{"go.sh": "#!/bin/sh\nmkdir " + lock + " || exit 1\nsleep 0.1\nrmdir " + lock +
+		"\necho 'pkg: example.com/app'\necho 'BenchmarkX-8 \t 100 \t 10 ns/op'\n"})
+	goBinary := filepath.Join(dir, "go.sh")
+	if err := os.Chmod(goBinary, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	config = &AppConfig{GoBinary: goBinary, Bench: ".", BenchCount: 1, BenchPackages: []string{"./..."}}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 3)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = runBenchmarks(&workspace{Dir: t.TempDir(), Out: &OutputNone{}})
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestMannWhitneyP(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []float64
+		want float64
+	}{
+		{"separated", []float64{1, 2, 3, 4, 5, 6}, []float64{7, 8, 9, 10, 11, 12}, 0.0051},
+		{"identical", []float64{5, 5, 5, 5}, []float64{5, 5, 5, 5}, 1},
+		{"interleaved", []float64{1, 3, 5, 7, 9, 11}, []float64{2, 4, 6, 8, 10, 12}, 0.6889},
+		{"empty", nil, []float64{1}, 1},
+	}
+	for _, tt := range tests {
+		if got := mannWhitneyP(tt.a, tt.b); math.Abs(got-tt.want) > 0.0001 {
+			t.Errorf("%s: mannWhitneyP = %.4f, want %.4f", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCompareBenchmarks(t *testing.T) {
+	config = &AppConfig{MaxBenchRegression: 10}
+	before := benchSamples{
+		"p.BenchmarkFast": {100, 101, 102, 103, 104, 105},
+		"p.BenchmarkSame": {200, 210, 190, 205, 195, 200},
+		"p.BenchmarkSlow": {1000, 1010, 990, 1005, 995, 1000},
+		"p.BenchmarkGone": {1},
+	}
+	after := benchSamples{
+		"p.BenchmarkFast": {90, 91, 92, 93, 94, 95},
+		"p.BenchmarkSame": {202, 208, 192, 204, 196, 199},
+		"p.BenchmarkSlow": {1200, 1210, 1190, 1205, 1195, 1200},
+		"p.BenchmarkNew":  {1},
+	}
+	got := compareBenchmarks(before, after)
+	names := make([]string, len(got))
+	for i, c := range got {
+		names[i] = c.Name + " " + c.DeltaString()
+		if c.Regression != (c.Name == "p.BenchmarkSlow") {
+			t.Errorf("%s: Regression = %v", c.Name, c.Regression)
+		}
+	}
+	want := []string{"p.BenchmarkFast -9.76%", "p.BenchmarkSame ~", "p.BenchmarkSlow +20.00%"}
+	if diff := cmp.Diff(want, names); diff != "" {
+		t.Errorf("compareBenchmarks mismatch (-want +got):\n%s", diff)
+	}
+	if got, want := got[2].String(), "p.BenchmarkSlow 1.00µs => 1.20µs +20.00% (p=0.005)"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestOutputMarkdownPrintBenchmarks(t *testing.T) {
+	var buf bytes.Buffer
+	out := NewOutputMarkdown(&buf)
+	out.printBenchmarks([]Result{
+		{ModulePath: "example.com/mod", Success: true, VersionBefore: "v1.0.0", VersionAfter: "v1.0.0", BenchBlocked: "v1.1.0",
+			Benchmarks: []BenchComparison{{Name: "p.BenchmarkParse", Before: 1500, After: 2100, Delta: 40, P: 0.002, Regression: true}}},
+		{ModulePath: "example.com/other", Success: true, VersionBefore: "v0.1.0", VersionAfter: "v0.2.0",
+			Benchmarks: []BenchComparison{{Name: "p.BenchmarkLex", Before: 80, After: 81, P: 0.699}}},
+	})
+	expected := "\n### Benchmarks\n" +
+		"\n`example.com/mod` v1.0.0 => v1.1.0 **blocked**:\n\n" +
+		"| Benchmark | Before | After | Delta | p |\n" +
+		"| --- | --- | --- | --- | --- |\n" +
+		"| p.BenchmarkParse | 1.50µs | 2.10µs | **+40.00%** | 0.002 |\n" +
+		"\n`example.com/other` v0.1.0 => v0.2.0:\n\n" +
+		"| Benchmark | Before | After | Delta | p |\n" +
+		"| --- | --- | --- | --- | --- |\n" +
+		"| p.BenchmarkLex | 80.00ns | 81.00ns | ~ | 0.699 |\n"
+	if diff := cmp.Diff(expected, buf.String()); diff != "" {
+		t.Errorf("printBenchmarks mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// BinarySize is the size of a main package built before and after a bump.
+type BinarySize struct {
+	Package string
+	Before  int64
+	After   int64
+}
+
+// Delta formats the size change, e.g. +1.2 MiB (+3.4%).
+func (s BinarySize) Delta() string {
+	diff := s.After - s.Before
+	sign := "+"
+	if diff < 0 {
+		sign, diff = "-", -diff
+	}
+	if s.Before == 0 {
+		return sign + formatSize(diff)
+	}
+	return fmt.Sprintf("%s%s (%+.1f%%)", sign, formatSize(diff), float64(s.After-s.Before)*100/float64(s.Before))
+}
+
+func (s BinarySize) String() string {
+	return fmt.Sprintf("%s %s => %s, %s", s.Package, formatSize(s.Before), formatSize(s.After), s.Delta())
+}
+
+// formatSize formats a byte count with binary units.
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, prefix := float64(n)/unit, 0
+	for value >= unit && prefix < 2 {
+		value /= unit
+		prefix++
+	}
+	return fmt.Sprintf("%.1f %ciB", value, "KMG"[prefix])
+}
+
+// sizeLimit is the largest acceptable growth of a binary, see parseSizeLimit.
+// It is the flag.Value of -max-size-growth.
+type sizeLimit struct {
+	bytes   int64   // 0 when not set
+	percent float64 // 0 when not set
+	text    string  // the -max-size-growth value
+}
+
+func (l *sizeLimit) String() string {
+	return l.text
+}
+
+func (l *sizeLimit) Set(value string) error {
+	limit, err := parseSizeLimit(value)
+	if err != nil {
+		return err
+	}
+	*l = limit
+	l.text = value
+	return nil
+}
+
+// parseSizeLimit parses a -max-size-growth value: a percentage such as 5%, or
+// a byte count with an optional binary unit such as 512K, 2MiB or 1G.
+func parseSizeLimit(s string) (sizeLimit, error) {
+	s = strings.TrimSpace(s)
+	if p, ok := strings.CutSuffix(s, "%"); ok {
+		percent, err := strconv.ParseFloat(p, 64)
+		if err != nil || percent < 0 {
+			return sizeLimit{}, fmt.Errorf("invalid percentage %q", s)
+		}
+		return sizeLimit{percent: percent}, nil
+	}
+	number := strings.TrimSuffix(strings.TrimSuffix(strings.ToUpper(s), "B"), "I")
+	multiplier := int64(1)
+	if i := strings.IndexAny(number, "KMG"); i != -1 && i == len(number)-1 {
+		multiplier = int64(1) << (10 * (strings.IndexByte("KMG", number[i]) + 1))
+		number = number[:i]
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(number), 10, 64)
+	if err != nil || n < 0 {
+		return sizeLimit{}, fmt.Errorf("invalid size %q", s)
+	}
+	return sizeLimit{bytes: n * multiplier}, nil
+}
+
+// exceeded returns why a size change is beyond the limit, or "".
+func (l sizeLimit) exceeded(s BinarySize) string {
+	diff := s.After - s.Before
+	if l.bytes > 0 && diff > l.bytes {
+		return fmt.Sprintf("%s grows by %s, more than %s", s.Package, formatSize(diff), formatSize(l.bytes))
+	}
+	if l.percent > 0 && s.Before > 0 && float64(diff)*100/float64(s.Before) > l.percent {
+		return fmt.Sprintf("%s grows by %s, more than %g%%", s.Package, s.Delta(), l.percent)
+	}
+	return ""
+}
+
+// binarySizes are the sizes of the -size-package binaries with the last good
+// go.mod: built before the run and updated after every accepted bump.
+var binarySizes map[string]int64
+
+// buildSizes builds every -size-package in the workspace and returns the binary sizes.
+func buildSizes(ws *workspace) (map[string]int64, error) {
+	tmp, err := os.MkdirTemp("", "gobump-size-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	sizes := map[string]int64{}
+	for i, pkg := range config.SizePackages {
+		bin := filepath.Join(tmp, strconv.Itoa(i))
+		if err := ws.cmd(config.GoBinary, "build", "-o", bin, pkg); err != nil {
+			return nil, fmt.Errorf("go build %s: %w", pkg, err)
+		}
+		st, err := os.Stat(bin)
+		if err != nil {
+			return nil, err
+		}
+		sizes[pkg] = st.Size()
+	}
+	return sizes, nil
+}
+
+// checkBinarySizes builds the -size-package binaries after upgrading to a
+// candidate and compares them to binarySizes. It reports whether the version
+// is acceptable: it builds and no binary grows beyond -max-size-growth;
+// otherwise go.mod is restored to revertTo.
+func checkBinarySizes(ws *workspace, revertTo *modfile.File) ([]BinarySize, bool) {
+	reject := func(msg string) ([]BinarySize, bool) {
+		ws.Out.Error(msg + "; reverting go.mod")
+		if err := saveMod(ws.GoModDst, revertTo); err != nil {
+			ws.Out.Error("failed to revert go.mod:", err.Error())
+		}
+		return nil, false
+	}
+	after, err := buildSizes(ws)
+	if err != nil {
+		return reject(err.Error())
+	}
+	var result []BinarySize
+	for _, pkg := range config.SizePackages {
+		s := BinarySize{Package: pkg, Before: binarySizes[pkg], After: after[pkg]}
+		if msg := config.SizeLimit.exceeded(s); msg != "" {
+			return reject(msg)
+		}
+		result = append(result, s)
+	}
+	return result, true
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseSizeLimit(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    sizeLimit
+		wantErr bool
+	}{
+		{"5%", sizeLimit{percent: 5}, false},
+		{"0.5%", sizeLimit{percent: 0.5}, false},
+		{"1000", sizeLimit{bytes: 1000}, false},
+		{"512K", sizeLimit{bytes: 512 << 10}, false},
+		{"2MiB", sizeLimit{bytes: 2 << 20}, false},
+		{"1gb", sizeLimit{bytes: 1 << 30}, false},
+		{"MB", sizeLimit{}, true},
+		{"-1%", sizeLimit{}, true},
+		{"2T", sizeLimit{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseSizeLimit(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseSizeLimit(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseSizeLimit(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSizeLimitFlag(t *testing.T) {
+	var limit sizeLimit
+	fs := flag.NewFlagSet("gobump", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Var(&limit, "max-size-growth", "")
+	if err := fs.Parse([]string{"-max-size-growth", "512K"}); err != nil {
+		t.Fatal(err)
+	}
+	if limit.bytes != 512<<10 || limit.String() != "512K" {
+		t.Errorf("limit = %+v", limit)
+	}
+	if err := fs.Parse([]string{"-max-size-growth", "lots"}); err == nil {
+		t.Error("invalid -max-size-growth accepted")
+	}
+}
+
+func TestSizeLimitExceeded(t *testing.T) {
+	s := BinarySize{Package: "./cmd/app", Before: 10 << 20, After: 11 << 20}
+	if got, want := s.String(), "./cmd/app 10.0 MiB => 11.0 MiB, +1.0 MiB (+10.0%)"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if got := (BinarySize{Before: 2048, After: 1024}).Delta(); got != "-1.0 KiB (-50.0%)" {
+		t.Errorf("Delta() = %q", got)
+	}
+	tests := []struct {
+		limit sizeLimit
+		want  string
+	}{
+		{sizeLimit{}, ""},
+		{sizeLimit{bytes: 2 << 20}, ""},
+		{sizeLimit{bytes: 512 << 10}, "./cmd/app grows by 1.0 MiB, more than 512.0 KiB"},
+		{sizeLimit{percent: 10}, ""},
+		{sizeLimit{percent: 5}, "./cmd/app grows by +1.0 MiB (+10.0%), more than 5%"},
+	}
+	for _, tt := range tests {
+		if got := tt.limit.exceeded(s); got != tt.want {
+			t.Errorf("%+v.exceeded() = %q, want %q", tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestBuildSizes(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":          "module example.com/app\n\ngo 1.22\n",
+		"cmd/app/main.go": "package main\n\nfunc main() {}\n",
+	}
+	for name, content := range files {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	config = &AppConfig{GoBinary: "go", SizePackages: []string{"./cmd/app"}}
+	ws := &workspace{Dir: dir, Out: &OutputNone{}}
+	sizes, err := buildSizes(ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sizes["./cmd/app"] == 0 {
+		t.Errorf("sizes = %v", sizes)
+	}
+	config.SizePackages = []string{"./cmd/missing"}
+	if _, err := buildSizes(ws); err == nil {
+		t.Error("expected an error for a missing package")
+	}
+}
+
+func TestOutputMarkdownPrintSummarySizes(t *testing.T) {
+	var buf bytes.Buffer
+	out := NewOutputMarkdown(&buf)
+	out.PrintSummary([]Result{
+		{ModulePath: "example.com/mod", Success: true, VersionBefore: "v1.0.0", VersionAfter: "v1.1.0",
+			BinarySizes: []BinarySize{{Package: "./cmd/app", Before: 4096, After: 5120}}},
+		{ModulePath: "example.com/failed", VersionBefore: "v1.0.0", VersionAfter: "v1.0.0"},
+	})
+	expected := `
+## Summary
+
+| Module | Status | Version | Size |
+| --- | --- | --- | --- |
+| example.com/mod | U | v1.0.0 > v1.1.0 | +1.0 KiB (+25.0%) |
+| example.com/failed | E | v1.0.0 > v1.0.0 | - |
+
+Status: **U** updated, **E** error, **X** excluded, **N** no newer versions on module proxy, **-** unchanged.
+`
+	if diff := cmp.Diff(expected, buf.String()); diff != "" {
+		t.Errorf("PrintSummary mismatch (-want +got):\n%s", diff)
+	}
+}
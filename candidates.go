@@ -0,0 +1,224 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+const (
+	updatePatch = "patch"
+	updateMinor = "minor"
+	updateMajor = "major"
+)
+
+// updateLevels are the accepted -update values; major allows every newer
+// version of the module path (major versions v2+ are separate module paths).
+var updateLevels = []string{updatePatch, updateMinor, updateMajor}
+
+// validateUpdate checks the -update value.
+func validateUpdate() error {
+	if !slices.Contains(updateLevels, config.Update) {
+		return fmt.Errorf("invalid -update %q, expected one of %v", config.Update, updateLevels)
+	}
+	return nil
+}
+
+// moduleUpdateLevel returns the update level of a module: its own setting in
+// the configuration file, else -update.
+func moduleUpdateLevel(modulePath string) string {
+	if m, ok := config.Modules[modulePath]; ok && m.Update != "" {
+		return m.Update
+	}
+	return config.Update
+}
+
+// withinUpdateLevel reports whether moving from current to version stays within level.
+func withinUpdateLevel(level, current, version string) bool {
+	switch level {
+	case updatePatch:
+		return semver.MajorMinor(version) == semver.MajorMinor(current)
+	case updateMinor:
+		return semver.Major(version) == semver.Major(current)
+	}
+	return true
+}
+
+// withinMax reports whether version does not exceed max; a shortened max such
+// as v1.9 or v1 includes all of its patch or minor releases.
+func withinMax(max, version string) bool {
+	if max == "" || semver.Compare(version, max) <= 0 {
+		return true
+	}
+	return semver.MajorMinor(max) == max && semver.MajorMinor(version) == max ||
+		semver.Major(max) == max && semver.Major(version) == max
+}
+
+// versionComparison is a single comparison such as <v2.0.0; a bare version means =.
+type versionComparison struct {
+	op      string
+	version string
+}
+
+var comparisonOps = []string{"<=", ">=", "<", ">", "="}
+
+func parseVersionComparison(s string) (versionComparison, error) {
+	vc := versionComparison{op: "=", version: s}
+	for _, op := range comparisonOps {
+		if v, ok := strings.CutPrefix(s, op); ok {
+			vc = versionComparison{op: op, version: v}
+			break
+		}
+	}
+	if !semver.IsValid(vc.version) {
+		return vc, fmt.Errorf("invalid version %q", vc.version)
+	}
+	return vc, nil
+}
+
+func (vc versionComparison) allows(version string) bool {
+	c := semver.Compare(version, vc.version)
+	switch vc.op {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	}
+	return c == 0
+}
+
+// upperBound reports whether the comparison caps versions.
+func (vc versionComparison) upperBound() bool {
+	return vc.op == "<" || vc.op == "<=" || vc.op == "="
+}
+
+// parseVersionRange parses comparisons separated by spaces or commas, all of
+// which must hold, e.g. ">=v1.2.0 <v1.10.0".
+func parseVersionRange(s string) ([]versionComparison, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool { return r == ' ' || r == ',' })
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty version range")
+	}
+	var result []versionComparison
+	for _, f := range fields {
+		vc, err := parseVersionComparison(f)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, vc)
+	}
+	return result, nil
+}
+
+// candidateBlocker returns the constraint that rules out version of modulePath
+// (currently at current), or "" when the version may be tried.
+func candidateBlocker(modulePath, current, version string) string {
+	if held, sel := config.HoldSelectors.match(module.Version{Path: modulePath, Version: version}); held {
+		return "hold " + sel.String()
+	}
+	if level := moduleUpdateLevel(modulePath); !withinUpdateLevel(level, current, version) {
+		return "update " + level
+	}
+	mc := config.Modules[modulePath]
+	if !withinMax(mc.Max, version) {
+		return "max " + mc.Max
+	}
+	if mc.Constraint != "" {
+		// Validated when the configuration file was loaded.
+		vr, _ := parseVersionRange(mc.Constraint)
+		for _, vc := range vr {
+			if !vc.allows(version) {
+				return "constraint " + mc.Constraint
+			}
+		}
+	}
+	if slices.Contains(mc.Ignore, version) {
+		return "ignore " + version
+	}
+	return ""
+}
+
+// filterCandidates drops the versions ruled out by candidateBlocker. When the
+// newest version is dropped, heldBy names the constraint that held it back.
+func filterCandidates(modulePath, current string, versions []module.Version) (result []module.Version, heldBy string) {
+	if len(versions) == 0 {
+		return nil, ""
+	}
+	newest := newestVersion(versions)
+	for _, v := range versions {
+		blocker := candidateBlocker(modulePath, current, v.Version)
+		if blocker == "" {
+			result = append(result, v)
+		} else if v.Version == newest {
+			heldBy = blocker
+		}
+	}
+	return result, heldBy
+}
+
+// obsoleteConstraints returns a warning for each per-module setting and -hold
+// selector that can no longer have any effect on mod.
+func obsoleteConstraints(mod *modfile.File) []string {
+	required := map[string]string{}
+	for _, r := range mod.Require {
+		required[r.Mod.Path] = r.Mod.Version
+	}
+	var warnings []string
+	for _, path := range sortedKeys(config.Modules) {
+		mc := config.Modules[path]
+		current, ok := required[path]
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("modules: %s is not required in %s", path, config.GoModSrc))
+			continue
+		}
+		if mc.Max != "" && !withinMax(mc.Max, current) {
+			warnings = append(warnings, fmt.Sprintf("modules: %s max %s is below the required %s", path, mc.Max, current))
+		}
+		if mc.Constraint != "" {
+			vr, _ := parseVersionRange(mc.Constraint)
+			exceeded := slices.ContainsFunc(vr, func(vc versionComparison) bool {
+				return vc.upperBound() && !vc.allows(current)
+			})
+			noEffect := !slices.ContainsFunc(vr, func(vc versionComparison) bool {
+				return vc.upperBound() || !vc.allows(current)
+			})
+			if exceeded {
+				warnings = append(warnings, fmt.Sprintf("modules: %s constraint %s does not allow the required %s", path, mc.Constraint, current))
+			} else if noEffect {
+				warnings = append(warnings, fmt.Sprintf("modules: %s constraint %s allows every version newer than the required %s", path, mc.Constraint, current))
+			}
+		}
+		for _, v := range mc.Ignore {
+			if semver.Compare(v, current) <= 0 {
+				warnings = append(warnings, fmt.Sprintf("modules: %s ignores %s, not newer than the required %s", path, v, current))
+			}
+		}
+	}
+	for _, sel := range config.HoldSelectors {
+		if sel.negate {
+			continue
+		}
+		matched, active := false, false
+		for path, current := range required {
+			if sel.matchesPath(path) {
+				matched = true
+				// Candidates are newer than current, so a cap at or below it matches none.
+				active = active || sel.version == nil || !sel.version.upperBound() || semver.Compare(current, sel.version.version) < 0
+			}
+		}
+		if !matched {
+			warnings = append(warnings, fmt.Sprintf("hold: %s matches no required module", sel))
+		} else if !active {
+			warnings = append(warnings, fmt.Sprintf("hold: %s does not match any version newer than the required ones", sel))
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+func TestFilterCandidates(t *testing.T) {
+	versions := []module.Version{{Version: "v2.0.0"}, {Version: "v1.10.0"}, {Version: "v1.9.3"}, {Version: "v1.8.2"}}
+	tests := []struct {
+		update string
+		mc     ModuleConfig
+		want   []string
+	}{
+		{updateMajor, ModuleConfig{}, []string{"v2.0.0", "v1.10.0", "v1.9.3", "v1.8.2"}},
+		{updateMinor, ModuleConfig{}, []string{"v1.10.0", "v1.9.3", "v1.8.2"}},
+		{updatePatch, ModuleConfig{}, []string{"v1.8.2"}},
+		{updateMajor, ModuleConfig{Update: updatePatch}, []string{"v1.8.2"}},
+		{updateMajor, ModuleConfig{Max: "v1.9"}, []string{"v1.9.3", "v1.8.2"}},
+		{updateMajor, ModuleConfig{Max: "v1.9.0"}, []string{"v1.8.2"}},
+		{updateMajor, ModuleConfig{Max: "v1"}, []string{"v1.10.0", "v1.9.3", "v1.8.2"}},
+	}
+	for _, tt := range tests {
+		config = &AppConfig{Update: tt.update, Modules: map[string]ModuleConfig{"example.com/a": tt.mc}}
+		var got []string
+		kept, _ := filterCandidates("example.com/a", "v1.8.0", versions)
+		for _, v := range kept {
+			got = append(got, v.Version)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s %+v: got %v, want %v", tt.update, tt.mc, got, tt.want)
+		}
+	}
+}
+
+func TestFilterCandidatesHeldBy(t *testing.T) {
+	config = &AppConfig{Update: updateMajor, Modules: map[string]ModuleConfig{"example.com/a": {Ignore: []string{"v1.4.0"}}}}
+	// Ascending, as securityCandidates returns them.
+	ascending := []module.Version{{Version: "v1.4.0"}, {Version: "v1.5.0"}, {Version: "v1.6.0"}}
+	if _, heldBy := filterCandidates("example.com/a", "v1.3.0", ascending); heldBy != "" {
+		t.Errorf("ignored oldest candidate: heldBy = %q, want none", heldBy)
+	}
+	config.Modules["example.com/a"] = ModuleConfig{Max: "v1.5.0"}
+	kept, heldBy := filterCandidates("example.com/a", "v1.3.0", ascending)
+	if heldBy != "max v1.5.0" {
+		t.Errorf("newest candidate blocked: heldBy = %q, want max v1.5.0", heldBy)
+	}
+	if len(kept) != 2 {
+		t.Errorf("kept = %v", kept)
+	}
+}
+
+func TestCandidateBlocker(t *testing.T) {
+	config = &AppConfig{
+		Update: updateMajor,
+		Hold:   commaSeparatedStringSlice{"example.com/held@>=v1.5.0"},
+		Modules: map[string]ModuleConfig{
+			"github.com/sirupsen/logrus": {Constraint: "<v1.10.0"},
+			"github.com/onsi/gomega":     {Ignore: []string{"v0.34.0"}},
+		},
+	}
+	if err := validateSelectors(); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path, version, want string
+	}{
+		{"github.com/sirupsen/logrus", "v1.9.3", ""},
+		{"github.com/sirupsen/logrus", "v1.10.0", "constraint <v1.10.0"},
+		{"github.com/onsi/gomega", "v0.34.0", "ignore v0.34.0"},
+		{"github.com/onsi/gomega", "v0.34.1", ""},
+		{"example.com/held", "v1.5.0", "hold example.com/held@>=v1.5.0"},
+		{"example.com/held", "v1.4.9", ""},
+	}
+	for _, tt := range tests {
+		if got := candidateBlocker(tt.path, "v0.1.0", tt.version); got != tt.want {
+			t.Errorf("candidateBlocker(%s@%s) = %q, want %q", tt.path, tt.version, got, tt.want)
+		}
+	}
+
+	versions := []module.Version{{Version: "v0.35.0"}, {Version: "v0.34.0"}, {Version: "v0.33.0"}}
+	config.Modules["github.com/onsi/gomega"] = ModuleConfig{Ignore: []string{"v0.35.0", "v0.34.0"}}
+	kept, heldBy := filterCandidates("github.com/onsi/gomega", "v0.32.0", versions)
+	if len(kept) != 1 || kept[0].Version != "v0.33.0" || heldBy != "ignore v0.35.0" {
+		t.Errorf("filterCandidates = %v, %q", kept, heldBy)
+	}
+}
+
+func TestObsoleteConstraints(t *testing.T) {
+	mod, err := modfile.Parse("go.mod", []byte(`module example.com/main
+
+go 1.22
+
+require (
+	example.com/a v1.10.0
+	example.com/b v1.2.0
+	example.com/c v0.35.0
+	example.com/d v1.0.0
+)
+`), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config = &AppConfig{
+		GoModSrc: "go.mod",
+		Hold:     commaSeparatedStringSlice{"example.com/gone", "example.com/d@<v1.0.0", "example.com/d@>=v2.0.0"},
+		Modules: map[string]ModuleConfig{
+			"example.com/a":    {Max: "v1.9", Constraint: "<v1.10.0"},
+			"example.com/b":    {Constraint: ">=v1.0.0"},
+			"example.com/c":    {Ignore: []string{"v0.34.0", "v0.36.0"}},
+			"example.com/gone": {Max: "v1.0.0"},
+		},
+	}
+	if err := validateSelectors(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"modules: example.com/a max v1.9 is below the required v1.10.0",
+		"modules: example.com/a constraint <v1.10.0 does not allow the required v1.10.0",
+		"modules: example.com/b constraint >=v1.0.0 allows every version newer than the required v1.2.0",
+		"modules: example.com/c ignores v0.34.0, not newer than the required v0.35.0",
+		"modules: example.com/gone is not required in go.mod",
+		"hold: example.com/gone matches no required module",
+		"hold: example.com/d@<v1.0.0 does not match any version newer than the required ones",
+	}
+	if diff := cmp.Diff(want, obsoleteConstraints(mod)); diff != "" {
+		t.Errorf("obsoleteConstraints mismatch (-want +got):\n%s", diff)
+	}
+}
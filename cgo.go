@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// validateCgoCheck checks the -cgo-check value.
+func validateCgoCheck() error {
+	return validateCheckMode("cgo-check", config.CgoCheck)
+}
+
+// listedPackage is the subset of go list -json output the cgo check needs.
+type listedPackage struct {
+	ImportPath string
+	CgoFiles   []string
+	Module     *struct {
+		Path string
+		Main bool
+	}
+	Error *struct {
+		Err string
+	}
+}
+
+// cgoPackages lists the dependency packages the main module and its tests
+// import that need cgo: they have cgo files when cgo is enabled, and with
+// CGO_ENABLED=0 they are not imported or fail to build, such as when build
+// constraints exclude all their files. Packages with a pure Go fallback for
+// !cgo builds are left out.
+func cgoPackages(ws *workspace) (map[string]bool, error) {
+	withCgo, err := listPackages(ws, "1")
+	if err != nil {
+		return nil, err
+	}
+	withoutCgo, err := listPackages(ws, "0")
+	if err != nil {
+		return nil, err
+	}
+	return cgoRequired(withCgo, withoutCgo), nil
+}
+
+// listPackages runs go list -deps -test on the main module with CGO_ENABLED
+// set to cgoEnabled. Without cgo the packages are compiled too (-export), so
+// that packages failing to build are returned with Error set.
+func listPackages(ws *workspace, cgoEnabled string) ([]listedPackage, error) {
+	args := []string{"list", "-e", "-deps", "-test", "-json=ImportPath,CgoFiles,Module,Error", "./..."}
+	if cgoEnabled == "0" {
+		args = slices.Insert(args, 1, "-export")
+	}
+	c := exec.Command(config.GoBinary, args...)
+	c.Dir = ws.Dir
+	c.Env = append(subprocessEnv(), "CGO_ENABLED="+cgoEnabled)
+	buf, err := c.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list: %w", err)
+	}
+	return parseListedPackages(buf)
+}
+
+// parseListedPackages reads go list -json output; test variants such as
+// "example.com/p [example.com/p.test]" are returned as their package.
+func parseListedPackages(buf []byte) ([]listedPackage, error) {
+	var packages []listedPackage
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	for {
+		var p listedPackage
+		if err := dec.Decode(&p); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to decode go list output: %w", err)
+		}
+		p.ImportPath, _, _ = strings.Cut(p.ImportPath, " ")
+		packages = append(packages, p)
+	}
+	return packages, nil
+}
+
+// cgoRequired returns the dependency packages with cgo files in withCgo that
+// are missing from withoutCgo or fail to build there.
+func cgoRequired(withCgo, withoutCgo []listedPackage) map[string]bool {
+	loads := map[string]bool{}
+	for _, p := range withoutCgo {
+		if p.Error == nil {
+			loads[p.ImportPath] = true
+		}
+	}
+	packages := map[string]bool{}
+	for _, p := range withCgo {
+		if p.Module == nil || p.Module.Main || len(p.CgoFiles) == 0 || loads[p.ImportPath] {
+			continue
+		}
+		packages[p.ImportPath] = true
+	}
+	return packages
+}
+
+// newCgoPackages returns the sorted packages in after that are not in before.
+func newCgoPackages(before, after map[string]bool) []string {
+	var result []string
+	for p := range after {
+		if !before[p] {
+			result = append(result, p)
+		}
+	}
+	slices.Sort(result)
+	return result
+}
+
+// checkCgo looks for imported packages that need cgo after upgrading to a
+// candidate with -cgo-check, before is the listing before the upgrade. It
+// reports whether the version is acceptable; with block, versions with new
+// cgo packages are rejected and go.mod is restored to revertTo.
+func checkCgo(ws *workspace, before map[string]bool, revertTo *modfile.File) ([]string, bool) {
+	after, err := cgoPackages(ws)
+	if err != nil {
+		ws.Out.Error("failed to list cgo packages:", err.Error())
+		return nil, true
+	}
+	added := newCgoPackages(before, after)
+	if len(added) == 0 || config.CgoCheck != checkBlock {
+		return added, true
+	}
+	ws.Out.Error("upgrade requires cgo in " + strings.Join(added, ", ") + "; reverting go.mod")
+	if err := saveMod(ws.GoModDst, revertTo); err != nil {
+		ws.Out.Error("failed to revert go.mod:", err.Error())
+	}
+	return added, false
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCgoPackages(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":               "module example.com/app\n\ngo 1.22\n\nrequire example.com/lib v1.0.0\n\nreplace example.com/lib => ./lib\n",
+		"main.go":              "package main\n\nimport _ \"example.com/lib/sqlite\"\n\nfunc main() {}\n",
+		"main_test.go":         "package main\n\nimport (\n\t\"testing\"\n\n\t_ \"example.com/lib/testonly\"\n)\n\nfunc TestX(t *testing.T) {}\n",
+		"cgo.go":               "package main\n\n// int x;\nimport \"C\"\n",
+		"lib/go.mod":           "module example.com/lib\n\ngo 1.22\n",
+		"lib/sqlite/sqlite.go": "package sqlite\n\n// int y;\nimport \"C\"\n\nfunc Open() int { return int(C.y) }\n",
+		"lib/testonly/t.go":    "package testonly\n\n// int z;\nimport \"C\"\n",
+		"lib/unused/unused.go": "package unused\n\n// int w;\nimport \"C\"\n",
+		"lib/fallback/cgo.go":  "package fallback\n\n// int v;\nimport \"C\"\n",
+		"lib/fallback/pure.go": "//go:build !cgo\n\npackage fallback\n",
+		"lib/sqlite/common.go": "package sqlite\n\nimport _ \"example.com/lib/fallback\"\n\nvar _ = Open\n",
+	}
+	for name, content := range files {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("CGO_ENABLED", "0")
+	config = &AppConfig{GoBinary: "go"}
+	got, err := cgoPackages(&workspace{Dir: dir, Out: &OutputNone{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]bool{"example.com/lib/sqlite": true, "example.com/lib/testonly": true}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("cgoPackages (-want +got):\n%s", diff)
+	}
+}
+
+func TestNewCgoPackages(t *testing.T) {
+	before := map[string]bool{"example.com/a": true, "example.com/b": true}
+	after := map[string]bool{"example.com/b": true, "example.com/d": true, "example.com/c": true}
+	if diff := cmp.Diff([]string{"example.com/c", "example.com/d"}, newCgoPackages(before, after)); diff != "" {
+		t.Errorf("newCgoPackages (-want +got):\n%s", diff)
+	}
+	if got := newCgoPackages(after, after); got != nil {
+		t.Errorf("newCgoPackages without changes = %v", got)
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+)
+
+const (
+	// checkOff skips an optional check of candidate versions.
+	checkOff = "off"
+	// checkWarn reports the findings of a check in the summary.
+	checkWarn = "warn"
+	// checkBlock rejects versions with findings, the next older candidate is tried.
+	checkBlock = "block"
+)
+
+// checkModes are the values of -license-check and -cgo-check.
+var checkModes = []string{checkOff, checkWarn, checkBlock}
+
+// validateCheckMode checks the value of a check mode flag such as -cgo-check.
+func validateCheckMode(flagName, mode string) error {
+	if !slices.Contains(checkModes, mode) {
+		return fmt.Errorf("invalid -%s %q, expected one of %v", flagName, mode, checkModes)
+	}
+	return nil
+}
@@ -0,0 +1,400 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"golang.org/x/mod/semver"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultConfigFile is the repository configuration file read when -config is not given.
+const defaultConfigFile = ".gobump.yaml"
+
+// ModuleConfig holds the settings of a single module from the configuration file.
+type ModuleConfig struct {
+	Update     string   `yaml:"update"`     // patch, minor or major; overrides the global update level
+	Max        string   `yaml:"max"`        // highest version to adopt; v1.9 allows any v1.9.x
+	Constraint string   `yaml:"constraint"` // version range to stay within, e.g. ">=v1.2.0 <v1.10.0"
+	Ignore     []string `yaml:"ignore"`     // versions never to adopt, e.g. known regressions
+	Reason     string   `yaml:"reason"`     // shown in the summary when the module is held
+	Hold       bool     `yaml:"-"`          // excluded by a gobump:hold annotation
+}
+
+// FileConfig is the repository configuration file. Scalar settings apply unless
+// the matching flag is given on the command line; exclude and exec lists are
+// combined with the command-line ones, and so are denied modules, size, test
+// and benchmark packages and allowed licenses.
+type FileConfig struct {
+	Exclude     []string `yaml:"exclude"`
+	Hold        []string `yaml:"hold"`
+	Exec        []string `yaml:"exec"`
+	Baseline    string   `yaml:"baseline"`
+	ExecRetries *int     `yaml:"exec_retries"`
+	Retries     *int     `yaml:"retries"`
+	Update      string   `yaml:"update"`
+	Policy      struct {
+		Go        string `yaml:"go"`
+		Toolchain string `yaml:"toolchain"`
+	} `yaml:"policy"`
+	Modules map[string]ModuleConfig `yaml:"modules"`
+	Groups  map[string][]string     `yaml:"groups"`
+	Commit  struct {
+		UserName  string `yaml:"user_name"`
+		UserEmail string `yaml:"user_email"`
+		Prefix    string `yaml:"prefix"`
+	} `yaml:"commit"`
+	Changelog struct {
+		Enabled *bool  `yaml:"enabled"`
+		Dest    string `yaml:"dest"`
+	} `yaml:"changelog"`
+	Graph struct {
+		MaxNewModules *int     `yaml:"max_new_modules"`
+		Deny          []string `yaml:"deny"`
+	} `yaml:"graph"`
+	Size struct {
+		Packages  []string `yaml:"packages"`
+		MaxGrowth string   `yaml:"max_growth"`
+	} `yaml:"size"`
+	Test struct {
+		Packages []string `yaml:"packages"`
+	} `yaml:"test"`
+	Bench struct {
+		Packages      []string `yaml:"packages"`
+		Pattern       string   `yaml:"pattern"`
+		Count         *int     `yaml:"count"`
+		MaxRegression *float64 `yaml:"max_regression"`
+	} `yaml:"bench"`
+	Cgo struct {
+		Check string `yaml:"check"`
+	} `yaml:"cgo"`
+	Licenses struct {
+		Check string   `yaml:"check"`
+		Allow []string `yaml:"allow"`
+	} `yaml:"licenses"`
+}
+
+// ConfigError is a problem found in the configuration file.
+type ConfigError struct {
+	File string
+	Line int // 0 when unknown
+	Msg  string
+}
+
+func (e ConfigError) Error() string {
+	if e.Line == 0 {
+		return e.File + ": " + e.Msg
+	}
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Msg)
+}
+
+var yamlLineRe = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// yamlErrors converts YAML decoding errors to ConfigErrors with line numbers.
+func yamlErrors(file string, err error) []ConfigError {
+	msgs := []string{err.Error()}
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		msgs = typeErr.Errors
+	}
+	var result []ConfigError
+	for _, msg := range msgs {
+		ce := ConfigError{File: file, Msg: msg}
+		if m := yamlLineRe.FindStringSubmatch(msg); m != nil {
+			ce.Line, _ = strconv.Atoi(m[1])
+			ce.Msg = m[2]
+		}
+		result = append(result, ce)
+	}
+	return result
+}
+
+// nodeLine returns the line of the value at the given mapping keys (or
+// sequence indexes), or 0.
+func nodeLine(root *yaml.Node, keys ...string) int {
+	node := root
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	for _, key := range keys {
+		if node.Kind == yaml.SequenceNode {
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node.Content) {
+				return 0
+			}
+			node = node.Content[i]
+			continue
+		}
+		if node.Kind != yaml.MappingNode {
+			return 0
+		}
+		found := false
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				node = node.Content[i+1]
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0
+		}
+	}
+	return node.Line
+}
+
+// loadConfigFile reads and validates a configuration file. A missing file is
+// only an error when it was named explicitly with -config.
+func loadConfigFile(file string, explicit bool) (*FileConfig, []ConfigError) {
+	buf, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) && !explicit {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, []ConfigError{{File: file, Msg: err.Error()}}
+	}
+
+	var fc FileConfig
+	dec := yaml.NewDecoder(bytes.NewReader(buf))
+	dec.KnownFields(true)
+	if err := dec.Decode(&fc); err != nil && !errors.Is(err, io.EOF) {
+		return nil, yamlErrors(file, err)
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(buf, &root); err != nil {
+		return nil, yamlErrors(file, err)
+	}
+	if errs := validateFileConfig(file, &fc, &root); len(errs) > 0 {
+		return nil, errs
+	}
+	return &fc, nil
+}
+
+// validateFileConfig checks values that decode fine but are not valid settings.
+func validateFileConfig(file string, fc *FileConfig, root *yaml.Node) []ConfigError {
+	var errs []ConfigError
+	add := func(msg string, keys ...string) {
+		errs = append(errs, ConfigError{File: file, Line: nodeLine(root, keys...), Msg: msg})
+	}
+
+	for _, key := range []string{"exclude", "hold"} {
+		list := fc.Exclude
+		if key == "hold" {
+			list = fc.Hold
+		}
+		for i, expr := range list {
+			if _, err := parseSelector(expr); err != nil {
+				add(err.Error(), key, strconv.Itoa(i))
+			}
+		}
+	}
+	for i, expr := range fc.Graph.Deny {
+		if _, err := parseSelector(expr); err != nil {
+			add(err.Error(), "graph", "deny", strconv.Itoa(i))
+		}
+	}
+	if fc.Graph.MaxNewModules != nil && *fc.Graph.MaxNewModules < 0 {
+		add("graph max_new_modules must not be negative", "graph", "max_new_modules")
+	}
+	if fc.Retries != nil && *fc.Retries < 0 {
+		add("retries must not be negative", "retries")
+	}
+	if fc.ExecRetries != nil && *fc.ExecRetries < 0 {
+		add("exec_retries must not be negative", "exec_retries")
+	}
+	if fc.Update != "" && !slices.Contains(updateLevels, fc.Update) {
+		add(fmt.Sprintf("invalid update level %q, expected one of %v", fc.Update, updateLevels), "update")
+	}
+	if fc.Policy.Go != "" && !slices.Contains(goPolicies, fc.Policy.Go) {
+		add(fmt.Sprintf("invalid go policy %q, expected one of %v", fc.Policy.Go, goPolicies), "policy", "go")
+	}
+	if fc.Policy.Toolchain != "" && !slices.Contains(toolchainPolicies, fc.Policy.Toolchain) {
+		add(fmt.Sprintf("invalid toolchain policy %q, expected one of %v", fc.Policy.Toolchain, toolchainPolicies), "policy", "toolchain")
+	}
+	if fc.Size.MaxGrowth != "" {
+		if _, err := parseSizeLimit(fc.Size.MaxGrowth); err != nil {
+			add(fmt.Sprintf("invalid size max_growth: %s", err), "size", "max_growth")
+		}
+	}
+	if fc.Bench.Count != nil && *fc.Bench.Count < 1 {
+		add("bench count must be at least 1", "bench", "count")
+	}
+	if fc.Bench.MaxRegression != nil && *fc.Bench.MaxRegression < 0 {
+		add("bench max_regression must not be negative", "bench", "max_regression")
+	}
+	if fc.Baseline != "" && !slices.Contains(baselineModes, fc.Baseline) {
+		add(fmt.Sprintf("invalid baseline %q, expected one of %v", fc.Baseline, baselineModes), "baseline")
+	}
+	if fc.Cgo.Check != "" && !slices.Contains(checkModes, fc.Cgo.Check) {
+		add(fmt.Sprintf("invalid cgo check %q, expected one of %v", fc.Cgo.Check, checkModes), "cgo", "check")
+	}
+	if fc.Licenses.Check != "" && !slices.Contains(checkModes, fc.Licenses.Check) {
+		add(fmt.Sprintf("invalid license check %q, expected one of %v", fc.Licenses.Check, checkModes), "licenses", "check")
+	}
+	for _, path := range sortedKeys(fc.Modules) {
+		m := fc.Modules[path]
+		if m.Update != "" && !slices.Contains(updateLevels, m.Update) {
+			add(fmt.Sprintf("invalid update level %q for %s, expected one of %v", m.Update, path, updateLevels), "modules", path, "update")
+		}
+		if m.Max != "" && !semver.IsValid(m.Max) {
+			add(fmt.Sprintf("invalid max version %q for %s", m.Max, path), "modules", path, "max")
+		}
+		if m.Constraint != "" {
+			if _, err := parseVersionRange(m.Constraint); err != nil {
+				add(fmt.Sprintf("invalid constraint %q for %s: %s", m.Constraint, path, err), "modules", path, "constraint")
+			}
+		}
+		for i, v := range m.Ignore {
+			if !semver.IsValid(v) {
+				add(fmt.Sprintf("invalid ignored version %q for %s", v, path), "modules", path, "ignore", strconv.Itoa(i))
+			}
+		}
+	}
+	groupOf := map[string]string{}
+	for _, name := range sortedKeys(fc.Groups) {
+		if len(fc.Groups[name]) == 0 {
+			add(fmt.Sprintf("group %s has no modules", name), "groups", name)
+		}
+		for _, path := range fc.Groups[name] {
+			if other, ok := groupOf[path]; ok {
+				add(fmt.Sprintf("module %s is in groups %s and %s", path, other, name), "groups", name)
+			}
+			groupOf[path] = name
+		}
+	}
+	return errs
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// applyFileConfig merges the configuration file into config; set holds the
+// names of flags given on the command line, which take precedence.
+func applyFileConfig(fc *FileConfig, set map[string]bool) {
+	for _, e := range fc.Exclude {
+		if !slices.Contains(config.Exclude, e) {
+			config.Exclude = append(config.Exclude, e)
+		}
+	}
+	for _, h := range fc.Hold {
+		if !slices.Contains(config.Hold, h) {
+			config.Hold = append(config.Hold, h)
+		}
+	}
+	for _, d := range fc.Graph.Deny {
+		if !slices.Contains(config.DenyModules, d) {
+			config.DenyModules = append(config.DenyModules, d)
+		}
+	}
+	for _, p := range fc.Size.Packages {
+		if !slices.Contains(config.SizePackages, p) {
+			config.SizePackages = append(config.SizePackages, p)
+		}
+	}
+	for _, p := range fc.Test.Packages {
+		if !slices.Contains(config.TestPackages, p) {
+			config.TestPackages = append(config.TestPackages, p)
+		}
+	}
+	for _, p := range fc.Bench.Packages {
+		if !slices.Contains(config.BenchPackages, p) {
+			config.BenchPackages = append(config.BenchPackages, p)
+		}
+	}
+	for _, l := range fc.Licenses.Allow {
+		if !slices.Contains(config.LicenseAllow, l) {
+			config.LicenseAllow = append(config.LicenseAllow, l)
+		}
+	}
+	config.Commands = append(stringSlice(slices.Clone(fc.Exec)), config.Commands...)
+	config.Modules = fc.Modules
+	config.Groups = fc.Groups
+
+	setString := func(flagName string, dst *string, value string) {
+		if value != "" && !set[flagName] {
+			*dst = value
+		}
+	}
+	setString("update", &config.Update, fc.Update)
+	setString("baseline", &config.Baseline, fc.Baseline)
+	setString("go-policy", &config.GoPolicy, fc.Policy.Go)
+	setString("toolchain-policy", &config.ToolchainPolicy, fc.Policy.Toolchain)
+	setString("user-name", &config.GitUserName, fc.Commit.UserName)
+	setString("user-email", &config.GitUserEmail, fc.Commit.UserEmail)
+	setString("commit-prefix", &config.CommitPrefix, fc.Commit.Prefix)
+	setString("changelog-dest", &config.ChangelogDest, fc.Changelog.Dest)
+	setString("license-check", &config.LicenseCheck, fc.Licenses.Check)
+	setString("cgo-check", &config.CgoCheck, fc.Cgo.Check)
+	setString("bench", &config.Bench, fc.Bench.Pattern)
+	if fc.Size.MaxGrowth != "" && !set["max-size-growth"] {
+		// Validated when the configuration file was loaded.
+		config.SizeLimit.Set(fc.Size.MaxGrowth)
+	}
+	if fc.Retries != nil && !set["retries"] {
+		config.Retries = *fc.Retries
+	}
+	if fc.ExecRetries != nil && !set["exec-retries"] {
+		config.ExecRetries = *fc.ExecRetries
+	}
+	if fc.Graph.MaxNewModules != nil && !set["max-new-modules"] {
+		config.MaxNewModules = *fc.Graph.MaxNewModules
+	}
+	if fc.Bench.Count != nil && !set["bench-count"] {
+		config.BenchCount = *fc.Bench.Count
+	}
+	if fc.Bench.MaxRegression != nil && !set["max-bench-regression"] {
+		config.MaxBenchRegression = *fc.Bench.MaxRegression
+	}
+	if fc.Changelog.Enabled != nil && !set["changelog"] {
+		config.Changelog = *fc.Changelog.Enabled
+	}
+}
+
+// runConfigCommand implements "gobump config validate" and returns the exit status.
+func runConfigCommand() int {
+	if len(config.Dependencies) != 1 || config.Dependencies[0] != "validate" {
+		out.Error("usage: gobump [-config FILE] config validate")
+		return ERR_ARGS
+	}
+	if len(config.ConfigErrors) > 0 {
+		for _, e := range config.ConfigErrors {
+			out.Error(e.Error())
+		}
+		return 1
+	}
+	if err := validateSelectors(); err != nil {
+		out.Error(err.Error())
+		return 1
+	}
+	if mod, err := parseMod(config.GoModSrc); err == nil {
+		annotations, errs := parseAnnotations(config.GoModSrc, mod)
+		if len(errs) > 0 {
+			for _, e := range errs {
+				out.Error(e.Error())
+			}
+			return 1
+		}
+		applyAnnotations(annotations)
+		for _, w := range obsoleteConstraints(mod) {
+			out.Println("warning: obsolete constraint: " + w)
+		}
+	}
+	if _, err := os.Stat(config.ConfigFile); err != nil {
+		out.Println(config.ConfigFile + ": not found, using defaults")
+		return 0
+	}
+	out.Println(config.ConfigFile + ": ok")
+	return 0
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), ".gobump.yaml")
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	file := writeConfigFile(t, `exclude:
+  - example.com/frozen
+update: minor
+modules:
+  example.com/a:
+    max: v1.9
+groups:
+  otel:
+    - example.com/otel
+    - example.com/otel/trace
+commit:
+  prefix: build(deps)
+`)
+	fc, errs := loadConfigFile(file, true)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if fc.Update != updateMinor || fc.Modules["example.com/a"].Max != "v1.9" || fc.Commit.Prefix != "build(deps)" {
+		t.Errorf("unexpected config %+v", fc)
+	}
+	if len(fc.Groups["otel"]) != 2 {
+		t.Errorf("groups = %v", fc.Groups)
+	}
+}
+
+func TestLoadConfigFileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []ConfigError
+	}{
+		{"unknown key", "exclude: []\nupdates: minor\n", []ConfigError{{Line: 2, Msg: "field updates not found in type main.FileConfig"}}},
+		{"wrong type", "retries: many\n", []ConfigError{{Line: 1, Msg: "cannot unmarshal !!str `many` into int"}}},
+		{"syntax", "exclude:\n  - a\n b: c\n", []ConfigError{{Line: 2, Msg: "did not find expected key"}}},
+		{"invalid values", "update: minr\nmodules:\n  example.com/a:\n    max: 1.9\npolicy:\n  toolchain: never\n", []ConfigError{
+			{Line: 1, Msg: `invalid update level "minr", expected one of [patch minor major]`},
+			{Line: 6, Msg: `invalid toolchain policy "never", expected one of [keep patch absent any]`},
+			{Line: 4, Msg: `invalid max version "1.9" for example.com/a`},
+		}},
+		{"selectors", "exclude:\n  - example.com/a\n  - example.com/b@1.0\nhold: [\"\"]\n", []ConfigError{
+			{Line: 3, Msg: `selector "example.com/b@1.0": invalid version "1.0"`},
+			{Line: 4, Msg: `selector "": empty module path`},
+		}},
+		{"constraints", "modules:\n  example.com/a:\n    constraint: <2.0\n    ignore: [v1.0.0, latest]\n", []ConfigError{
+			{Line: 3, Msg: `invalid constraint "<2.0" for example.com/a: invalid version "2.0"`},
+			{Line: 4, Msg: `invalid ignored version "latest" for example.com/a`},
+		}},
+		{"graph", "graph:\n  max_new_modules: -1\n  deny: [\"example.com/x@1\"]\n", []ConfigError{
+			{Line: 3, Msg: `selector "example.com/x@1": invalid version "1"`},
+			{Line: 2, Msg: "graph max_new_modules must not be negative"},
+		}},
+		{"size", "size:\n  packages: [./cmd/app]\n  max_growth: lots\n", []ConfigError{
+			{Line: 3, Msg: `invalid size max_growth: invalid size "lots"`},
+		}},
+		{"bench", "bench:\n  packages: [./internal/parser]\n  count: 0\n  max_regression: -5\n", []ConfigError{
+			{Line: 3, Msg: "bench count must be at least 1"},
+			{Line: 4, Msg: "bench max_regression must not be negative"},
+		}},
+		{"baseline", "exec: [make check]\nbaseline: ignore\nexec_retries: -1\n", []ConfigError{
+			{Line: 3, Msg: "exec_retries must not be negative"},
+			{Line: 2, Msg: `invalid baseline "ignore", expected one of [abort tolerate off]`},
+		}},
+		{"checks", "cgo:\n  check: fail\nlicenses:\n  check: strict\n", []ConfigError{
+			{Line: 2, Msg: `invalid cgo check "fail", expected one of [off warn block]`},
+			{Line: 4, Msg: `invalid license check "strict", expected one of [off warn block]`},
+		}},
+		{"groups", "groups:\n  a: [example.com/x]\n  b: [example.com/x]\n  c: []\n", []ConfigError{
+			{Line: 3, Msg: "module example.com/x is in groups a and b"},
+			{Line: 4, Msg: "group c has no modules"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := writeConfigFile(t, tt.content)
+			_, errs := loadConfigFile(file, true)
+			for i := range tt.want {
+				tt.want[i].File = file
+			}
+			if diff := cmp.Diff(tt.want, errs); diff != "" {
+				t.Errorf("errors mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLoadConfigFileMissing(t *testing.T) {
+	file := filepath.Join(t.TempDir(), ".gobump.yaml")
+	if fc, errs := loadConfigFile(file, false); fc != nil || errs != nil {
+		t.Errorf("missing default file: got %v, %v", fc, errs)
+	}
+	if _, errs := loadConfigFile(file, true); len(errs) != 1 {
+		t.Errorf("missing explicit file: got %v", errs)
+	}
+}
+
+func TestApplyFileConfig(t *testing.T) {
+	retries := 2
+	fc := &FileConfig{Exclude: []string{"example.com/a", "example.com/b"}, Exec: []string{"make check"}, Retries: &retries, Update: updatePatch}
+	fc.Commit.Prefix = "build(deps)"
+	fc.Commit.UserName = "Bot"
+	fc.Size.MaxGrowth = "5%"
+
+	config = &AppConfig{
+		Exclude:      []string{"example.com/a"},
+		Commands:     stringSlice{"go test ./..."},
+		Retries:      5,
+		Update:       updateMajor,
+		CommitPrefix: "chore(deps)",
+		GitUserName:  "Cli",
+	}
+	applyFileConfig(fc, map[string]bool{"retries": true, "user-name": true})
+
+	if !slices.Equal(config.Exclude, []string{"example.com/a", "example.com/b"}) {
+		t.Errorf("Exclude = %v", config.Exclude)
+	}
+	if !slices.Equal(config.Commands, stringSlice{"make check", "go test ./..."}) {
+		t.Errorf("Commands = %v", config.Commands)
+	}
+	if config.Retries != 5 || config.GitUserName != "Cli" {
+		t.Errorf("command-line flags overridden: retries %d, user name %s", config.Retries, config.GitUserName)
+	}
+	if config.Update != updatePatch || config.CommitPrefix != "build(deps)" {
+		t.Errorf("file settings not applied: update %s, prefix %s", config.Update, config.CommitPrefix)
+	}
+	if config.SizeLimit.percent != 5 || config.SizeLimit.String() != "5%" {
+		t.Errorf("size limit not parsed: %+v", config.SizeLimit)
+	}
+}
+
+func TestGroupRequires(t *testing.T) {
+	config = &AppConfig{Groups: map[string][]string{"g": {"example.com/b", "example.com/d"}}}
+	requires := []*modfile.Require{
+		{Mod: module.Version{Path: "example.com/a"}},
+		{Mod: module.Version{Path: "example.com/b"}},
+		{Mod: module.Version{Path: "example.com/c"}},
+		{Mod: module.Version{Path: "example.com/d"}},
+	}
+	var got []string
+	for _, r := range groupRequires(requires) {
+		got = append(got, r.Mod.Path)
+	}
+	want := []string{"example.com/a", "example.com/b", "example.com/d", "example.com/c"}
+	if !slices.Equal(got, want) {
+		t.Errorf("groupRequires = %v, want %v", got, want)
+	}
+}
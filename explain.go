@@ -0,0 +1,221 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// maxExplainModFiles bounds how many go.mod files explain inspects while
+// walking the requirements of a single candidate version.
+const maxExplainModFiles = 500
+
+// errTooManyModFiles is reported when a version needs more than maxExplainModFiles go.mod files.
+var errTooManyModFiles = fmt.Errorf("analysis incomplete: more than %d go.mod files to inspect", maxExplainModFiles)
+
+// VersionBlockers lists why one newer version of a module cannot be adopted.
+type VersionBlockers struct {
+	Version string   `json:"version"`
+	Reasons []string `json:"reasons"` // empty when no blocker is visible in go.mod metadata
+}
+
+// ModuleExplanation is the result of the explain subcommand.
+type ModuleExplanation struct {
+	ModulePath string            `json:"module"`
+	Version    string            `json:"version"`
+	Indirect   bool              `json:"indirect"`
+	PinnedGo   string            `json:"pinned_go"`
+	Versions   []VersionBlockers `json:"versions"`
+	Importers  []string          `json:"importers"` // packages of the main module importing the dependency
+	Why        []string          `json:"why"`       // go mod why -m output
+	Error      string            `json:"error,omitempty"`
+}
+
+// explainer caches go.mod files fetched from the module proxy.
+type explainer struct {
+	proxy     *GoProxy
+	pinned    string
+	mods      map[string]*modfile.File
+	inspected int // go.mod files inspected for the current version
+}
+
+func (e *explainer) modFile(path, version string) (*modfile.File, error) {
+	if e.inspected >= maxExplainModFiles {
+		return nil, errTooManyModFiles
+	}
+	e.inspected++
+	key := path + "@" + version
+	if mod, ok := e.mods[key]; ok {
+		return mod, nil
+	}
+	mod, err := e.proxy.FetchModFile(path, version)
+	if err != nil {
+		return nil, err
+	}
+	e.mods[key] = mod
+	return mod, nil
+}
+
+// transitiveGoBlocker walks the requirements mod raises above have and returns
+// the first chain that ends in a module requiring a Go newer than the pinned one.
+func (e *explainer) transitiveGoBlocker(mod *modfile.File, have map[string]string) (string, error) {
+	type node struct {
+		req   *modfile.Require
+		chain []string
+	}
+	var queue []node
+	for _, r := range mod.Require {
+		queue = append(queue, node{req: r})
+	}
+	seen := map[string]bool{}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		key := n.req.Mod.String()
+		if seen[key] || semver.Compare(n.req.Mod.Version, have[n.req.Mod.Path]) <= 0 {
+			continue
+		}
+		seen[key] = true
+		chain := append(slices.Clone(n.chain), key)
+		dep, err := e.modFile(n.req.Mod.Path, n.req.Mod.Version)
+		if err != nil {
+			return "", err
+		}
+		if !goCompatible(dep, e.pinned) {
+			return fmt.Sprintf("requires %s which requires go %s", strings.Join(chain, " -> "), dep.Go.Version), nil
+		}
+		for _, r := range dep.Require {
+			queue = append(queue, node{req: r, chain: chain})
+		}
+	}
+	return "", nil
+}
+
+// versionBlockers returns the reasons a single version cannot be adopted.
+func (e *explainer) versionBlockers(path, version string, retractions []*modfile.Retract, have map[string]string) []string {
+	e.inspected = 0
+	reasons := []string{}
+	if sel := excludedBy(module.Version{Path: path, Version: have[path]}); sel == holdAnnotation {
+		reasons = append(reasons, "held by "+holdAnnotation+" in "+config.GoModSrc+reasonSuffix(config.Modules[path].Reason))
+	} else if sel != "" {
+		reasons = append(reasons, "excluded by -exclude "+sel)
+	}
+	if blocker := candidateBlocker(path, have[path], version); blocker != "" {
+		reasons = append(reasons, "held back by "+blocker)
+	}
+	for _, r := range retractions {
+		if semver.Compare(version, r.Low) >= 0 && semver.Compare(version, r.High) <= 0 {
+			reasons = append(reasons, "retracted: "+strOrDash(r.Rationale))
+		}
+	}
+	mod, err := e.modFile(path, version)
+	if err != nil {
+		return append(reasons, "failed to fetch go.mod: "+err.Error())
+	}
+	if !goCompatible(mod, e.pinned) {
+		reasons = append(reasons, "requires go "+mod.Go.Version)
+	}
+	blocker, err := e.transitiveGoBlocker(mod, have)
+	if errors.Is(err, errTooManyModFiles) {
+		reasons = append(reasons, err.Error())
+	} else if err != nil {
+		reasons = append(reasons, "failed to inspect requirements: "+err.Error())
+	} else if blocker != "" {
+		reasons = append(reasons, blocker)
+	}
+	return reasons
+}
+
+// explainModule explains for every version newer than the required one why it cannot be adopted.
+func explainModule(original *modfile.File, modulePath string) ModuleExplanation {
+	e := &explainer{
+		proxy:  NewGoProxy(config.ModuleProxy),
+		pinned: pinnedGo(original),
+		mods:   map[string]*modfile.File{},
+	}
+	ex := ModuleExplanation{ModulePath: modulePath, PinnedGo: e.pinned}
+
+	have := map[string]string{}
+	for _, r := range original.Require {
+		have[r.Mod.Path] = r.Mod.Version
+		if r.Mod.Path == modulePath {
+			ex.Version = r.Mod.Version
+			ex.Indirect = r.Indirect
+		}
+	}
+	if ex.Version == "" {
+		ex.Error = fmt.Sprintf("%s is not required by %s", modulePath, config.GoModSrc)
+		return ex
+	}
+
+	versions, err := e.proxy.FetchVersions(modulePath, ex.Version)
+	if err != nil {
+		ex.Error = err.Error()
+		return ex
+	}
+	var retractions []*modfile.Retract
+	if len(versions) > 0 {
+		// Retractions are read from the go.mod of the latest version.
+		if latest, err := e.modFile(modulePath, versions[0].Version); err == nil {
+			retractions = latest.Retract
+		}
+	}
+	for _, v := range versions {
+		ex.Versions = append(ex.Versions, VersionBlockers{
+			Version: v.Version,
+			Reasons: e.versionBlockers(modulePath, v.Version, retractions, have),
+		})
+	}
+
+	ex.Importers, err = moduleImporters(modulePath)
+	if err != nil {
+		out.Error("failed to list importing packages:", err.Error())
+	}
+	why, err := cmdOutput(config.GoBinary, "mod", "why", "-m", modulePath)
+	if err != nil {
+		out.Error("go mod why failed:", err.Error())
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(why)), "\n") {
+		if line != "" {
+			ex.Why = append(ex.Why, line)
+		}
+	}
+	return ex
+}
+
+// moduleImporters returns the packages of the main module (including tests)
+// that import a package provided by modulePath.
+func moduleImporters(modulePath string) ([]string, error) {
+	deps, err := cmdOutput(config.GoBinary, "list", "-deps", "-test", "-f", "{{.ImportPath}} {{with .Module}}{{.Path}}{{end}}", "./...")
+	if err != nil {
+		return nil, fmt.Errorf("go list -deps: %w", err)
+	}
+	provided := map[string]bool{}
+	for _, line := range strings.Split(string(deps), "\n") {
+		pkg, mod, _ := strings.Cut(line, " ")
+		if mod == modulePath {
+			provided[pkg] = true
+		}
+	}
+
+	imports, err := cmdOutput(config.GoBinary, "list", "-f", "{{.ImportPath}} {{join .Imports \" \"}} {{join .TestImports \" \"}} {{join .XTestImports \" \"}}", "./...")
+	if err != nil {
+		return nil, fmt.Errorf("go list: %w", err)
+	}
+	var importers []string
+	for _, line := range strings.Split(string(imports), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		if slices.ContainsFunc(fields[1:], func(imp string) bool { return provided[imp] }) {
+			importers = append(importers, fields[0])
+		}
+	}
+	return importers, nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/mod/modfile"
+)
+
+func TestVersionBlockers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/example.com/mod/@v/v1.3.0.mod":
+			fmt.Fprintln(w, "module example.com/mod\n\ngo 1.22\n\nretract v1.2.0 // broken release")
+		case "/example.com/mod/@v/v1.2.0.mod":
+			fmt.Fprintln(w, "module example.com/mod\n\ngo 1.22")
+		case "/example.com/mod/@v/v1.1.0.mod":
+			fmt.Fprintln(w, "module example.com/mod\n\ngo 1.22\n\nrequire example.com/dep v1.5.0")
+		case "/example.com/dep/@v/v1.5.0.mod":
+			fmt.Fprintln(w, "module example.com/dep\n\ngo 1.24.0")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	config = &AppConfig{}
+	e := &explainer{proxy: NewGoProxy(server.URL), pinned: "go1.22.0", mods: map[string]*modfile.File{}}
+	latest, err := e.modFile("example.com/mod", "v1.3.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		version string
+		have    map[string]string
+		want    []string
+	}{
+		{"v1.3.0", nil, []string{}},
+		{"v1.2.0", nil, []string{"retracted: broken release"}},
+		{"v1.1.0", nil, []string{"requires example.com/dep@v1.5.0 which requires go 1.24.0"}},
+		{"v1.1.0", map[string]string{"example.com/dep": "v1.5.0"}, []string{}},
+	}
+	for _, tt := range tests {
+		got := e.versionBlockers("example.com/mod", tt.version, latest.Retract, tt.have)
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("versionBlockers(%s) mismatch (-want +got):\n%s", tt.version, diff)
+		}
+	}
+
+	config = &AppConfig{Exclude: commaSeparatedStringSlice{"example.com/*"}, Hold: commaSeparatedStringSlice{"example.com/mod@>=v1.3.0"}}
+	if err := validateSelectors(); err != nil {
+		t.Fatal(err)
+	}
+	got := e.versionBlockers("example.com/mod", "v1.3.0", nil, nil)
+	if diff := cmp.Diff([]string{"excluded by -exclude example.com/*", "held back by hold example.com/mod@>=v1.3.0"}, got); diff != "" {
+		t.Errorf("excluded mismatch (-want +got):\n%s", diff)
+	}
+
+	config = &AppConfig{Update: updateMinor, Modules: map[string]ModuleConfig{"example.com/mod": {Max: "v1.2.0"}}}
+	got = e.versionBlockers("example.com/mod", "v1.3.0", nil, map[string]string{"example.com/mod": "v1.1.0"})
+	if diff := cmp.Diff([]string{"held back by max v1.2.0"}, got); diff != "" {
+		t.Errorf("max mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestVersionBlockersModFileLimit(t *testing.T) {
+	// example.com/chain/vN requires example.com/chain/vN+1 up to n.
+	var n int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, version, ok := strings.Cut(strings.TrimSuffix(r.URL.Path, ".mod"), "/@v/")
+		i, err := strconv.Atoi(strings.TrimPrefix(path, "/example.com/chain"))
+		if !ok || err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, "module example.com/chain%d\n\ngo 1.22\n", i)
+		if i < n {
+			fmt.Fprintf(w, "require example.com/chain%d %s\n", i+1, version)
+		}
+	}))
+	defer server.Close()
+
+	config = &AppConfig{}
+	e := &explainer{proxy: NewGoProxy(server.URL), pinned: "go1.22.0", mods: map[string]*modfile.File{}}
+	// Both versions stay within the limit, though together they exceed it.
+	n = maxExplainModFiles - 1
+	for _, version := range []string{"v1.0.0", "v1.1.0"} {
+		if got := e.versionBlockers("example.com/chain0", version, nil, nil); len(got) != 0 {
+			t.Errorf("versionBlockers(%s) = %q, want none", version, got)
+		}
+	}
+	n = maxExplainModFiles
+	got := e.versionBlockers("example.com/chain0", "v1.2.0", nil, nil)
+	if diff := cmp.Diff([]string{errTooManyModFiles.Error()}, got); diff != "" {
+		t.Errorf("versionBlockers mismatch (-want +got):\n%s", diff)
+	}
+}
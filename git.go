@@ -56,7 +56,7 @@ func gitInsideWorkTree() bool {
 
 func gitRun(args ...string) error {
 	c := exec.Command("git", args...)
-	c.Env = os.Environ()
+	c.Env = subprocessEnv()
 	return c.Run()
 }
 
@@ -85,8 +85,13 @@ func gitWorktreeDiffersFromHEAD() bool {
 }
 
 func gitResetHardHEAD() error {
-	if err := gitRun("reset", "--hard", "HEAD"); err != nil {
-		return fmt.Errorf("git reset --hard HEAD: %w", err)
+	return gitResetHard("HEAD")
+}
+
+// gitResetHard resets the work tree to rev and removes untracked files.
+func gitResetHard(rev string) error {
+	if err := gitRun("reset", "--hard", rev); err != nil {
+		return fmt.Errorf("git reset --hard %s: %w", rev, err)
 	}
 	if err := gitRun("clean", "-fdq"); err != nil {
 		return fmt.Errorf("git clean -fdq: %w", err)
@@ -112,6 +117,24 @@ func goModTidy() error {
 }
 
 func gitCommitDependencyBump(modulePath, versionBefore, versionAfter string) error {
+	msg := fmt.Sprintf("%s: update %s to %s", config.CommitPrefix, modulePath, versionAfter)
+	if fixes := advisoriesFixed(modulePath, versionBefore, versionAfter); len(fixes) > 0 {
+		msg += "\n\nFixes " + advisoryList(fixes, Advisory.String)
+	}
+	if changes := apiChangeList(modulePath, versionBefore, versionAfter); len(changes) > 0 {
+		msg += "\n\nIncompatible API changes:\n\n- " + strings.Join(changes, "\n- ")
+	}
+	if issues := cachedLicenseIssues(modulePath, versionBefore, versionAfter); len(issues) > 0 {
+		msg += "\n\nLicense warnings:\n\n- " + strings.Join(issues, "\n- ")
+	}
+	if config.Changelog {
+		msg += formatModuleChangelog(modulePath, versionBefore, versionAfter)
+	}
+	return gitCommitGoMod(msg)
+}
+
+// gitCommitGoMod runs go mod tidy and commits go.mod and go.sum with msg.
+func gitCommitGoMod(msg string) error {
 	if err := gitEnsureUserIdentity(); err != nil {
 		return err
 	}
@@ -152,10 +175,6 @@ func gitCommitDependencyBump(modulePath, versionBefore, versionAfter string) err
 	if err := gitRun(addArgs...); err != nil {
 		return fmt.Errorf("git add: %w", err)
 	}
-	msg := fmt.Sprintf("chore(deps): update %s to %s", modulePath, versionAfter)
-	if config.Changelog {
-		msg += formatModuleChangelog(modulePath, versionBefore, versionAfter)
-	}
 	if err := gitRun("commit", "-m", msg); err != nil {
 		return fmt.Errorf("git commit: %w", err)
 	}
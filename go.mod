@@ -6,6 +6,11 @@ require (
 	github.com/google/go-cmp v0.6.0
 	golang.org/x/mod v0.30.0
 	golang.org/x/term v0.35.0
+	golang.org/x/tools v0.38.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
-require golang.org/x/sys v0.36.0 // indirect
+require (
+	golang.org/x/sync v0.17.0 // indirect
+	golang.org/x/sys v0.37.0 // indirect
+)
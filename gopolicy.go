@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"go/version"
+	"maps"
+	"slices"
+
+	"golang.org/x/mod/modfile"
+)
+
+const (
+	// policyKeep rejects any change of the directive.
+	policyKeep = "keep"
+	// policyPatch allows patch releases within the same Go language version (e.g. 1.22.0 => 1.22.5).
+	policyPatch = "patch"
+	// policyAbsent requires the toolchain directive to stay absent.
+	policyAbsent = "absent"
+	// policyAny accepts any toolchain directive change.
+	policyAny = "any"
+)
+
+var (
+	goPolicies        = []string{policyKeep, policyPatch}
+	toolchainPolicies = []string{policyKeep, policyPatch, policyAbsent, policyAny}
+)
+
+// validatePolicies checks the -go-policy and -toolchain-policy values.
+func validatePolicies() error {
+	if !slices.Contains(goPolicies, config.GoPolicy) {
+		return fmt.Errorf("invalid -go-policy %q, expected one of %v", config.GoPolicy, goPolicies)
+	}
+	if !slices.Contains(toolchainPolicies, config.ToolchainPolicy) {
+		return fmt.Errorf("invalid -toolchain-policy %q, expected one of %v", config.ToolchainPolicy, toolchainPolicies)
+	}
+	return nil
+}
+
+// goRelease converts a go directive value to a go/version release name. A bare
+// language version such as 1.22 is treated as its first release 1.22.0, which
+// is how go get rewrites it.
+func goRelease(v string) string {
+	gv := "go" + v
+	if version.IsValid(gv) && version.Lang(gv) == gv {
+		gv += ".0"
+	}
+	return gv
+}
+
+// toolchainName returns the toolchain directive name, or "" when absent.
+func toolchainName(mod *modfile.File) string {
+	if mod.Toolchain == nil {
+		return ""
+	}
+	return mod.Toolchain.Name
+}
+
+// validateGoDirective checks a go directive change against -go-policy.
+func validateGoDirective(before, after string) error {
+	b, a := goRelease(before), goRelease(after)
+	if version.Compare(a, b) == 0 {
+		return nil
+	}
+	if config.GoPolicy == policyPatch && version.Lang(a) == version.Lang(b) && version.Compare(a, b) > 0 {
+		return nil
+	}
+	return fmt.Errorf("upgrade changes required Go version %s => %s", before, after)
+}
+
+// validateToolchain checks a toolchain directive change against -toolchain-policy;
+// goVersion is the go directive the toolchain must stay within for the patch policy.
+func validateToolchain(before, after, goVersion string) error {
+	switch config.ToolchainPolicy {
+	case policyAny:
+		return nil
+	case policyAbsent:
+		if after != "" {
+			return fmt.Errorf("upgrade adds toolchain %s", after)
+		}
+		return nil
+	}
+	if before == after {
+		return nil
+	}
+	if config.ToolchainPolicy == policyPatch {
+		base := before
+		if base == "" {
+			base = goRelease(goVersion)
+		}
+		if after == "" || version.Lang(after) == version.Lang(base) && version.Compare(after, base) >= 0 {
+			return nil
+		}
+	}
+	return fmt.Errorf("upgrade changes toolchain %s => %s", strOrDash(before), strOrDash(after))
+}
+
+func godebugSettings(mod *modfile.File) map[string]string {
+	settings := map[string]string{}
+	for _, g := range mod.Godebug {
+		settings[g.Key] = g.Value
+	}
+	return settings
+}
+
+// validateDirectives checks the go, toolchain and godebug directives of newMod
+// against originalMod and the configured policies.
+func validateDirectives(originalMod, newMod *modfile.File) error {
+	if err := validateGoDirective(originalMod.Go.Version, newMod.Go.Version); err != nil {
+		return err
+	}
+	if err := validateToolchain(toolchainName(originalMod), toolchainName(newMod), originalMod.Go.Version); err != nil {
+		return err
+	}
+	if !maps.Equal(godebugSettings(originalMod), godebugSettings(newMod)) {
+		return fmt.Errorf("upgrade changes godebug settings")
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"testing"
+
+	"golang.org/x/mod/modfile"
+)
+
+func TestValidateGoDirective(t *testing.T) {
+	tests := []struct {
+		policy, before, after string
+		wantErr               bool
+	}{
+		{policyKeep, "1.22", "1.22", false},
+		{policyKeep, "1.22", "1.22.0", false},
+		{policyKeep, "1.22.0", "1.22", false},
+		{policyKeep, "1.22.0", "1.22.5", true},
+		{policyKeep, "1.22", "1.23.0", true},
+		{policyPatch, "1.22", "1.22.5", false},
+		{policyPatch, "1.22.5", "1.22.1", true},
+		{policyPatch, "1.22.5", "1.23.0", true},
+	}
+	for _, tt := range tests {
+		config = &AppConfig{GoPolicy: tt.policy}
+		err := validateGoDirective(tt.before, tt.after)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: %s => %s: err = %v, wantErr %v", tt.policy, tt.before, tt.after, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateToolchain(t *testing.T) {
+	tests := []struct {
+		policy, before, after string
+		wantErr               bool
+	}{
+		{policyKeep, "", "", false},
+		{policyKeep, "", "go1.22.5", true},
+		{policyKeep, "go1.22.5", "go1.22.5", false},
+		{policyKeep, "go1.22.5", "", true},
+		{policyPatch, "", "go1.22.5", false},
+		{policyPatch, "", "go1.23.1", true},
+		{policyPatch, "go1.22.5", "go1.22.7", false},
+		{policyPatch, "go1.22.5", "go1.22.1", true},
+		{policyPatch, "go1.22.5", "", false},
+		{policyAbsent, "", "", false},
+		{policyAbsent, "", "go1.22.5", true},
+		{policyAny, "go1.22.5", "go1.24.0", false},
+	}
+	for _, tt := range tests {
+		config = &AppConfig{ToolchainPolicy: tt.policy}
+		err := validateToolchain(tt.before, tt.after, "1.22")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: %q => %q: err = %v, wantErr %v", tt.policy, tt.before, tt.after, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateDirectivesGodebug(t *testing.T) {
+	parse := func(content string) *modfile.File {
+		mod, err := modfile.Parse("go.mod", []byte(content), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return mod
+	}
+	config = &AppConfig{GoPolicy: policyKeep, ToolchainPolicy: policyKeep}
+	before := parse("module example.com/m\n\ngo 1.22\n\ngodebug default=go1.21\n")
+	if err := validateDirectives(before, parse("module example.com/m\n\ngo 1.22.0\n\ngodebug default=go1.21\n")); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := validateDirectives(before, parse("module example.com/m\n\ngo 1.22\n\ngodebug default=go1.22\n")); err == nil {
+		t.Error("expected godebug change to be rejected")
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// moduleGraph is the requirement graph printed by go mod graph, keyed by
+// "path@version" nodes (the main module node has no version).
+type moduleGraph map[string][]string
+
+// parseModuleGraph parses go mod graph output into a moduleGraph.
+func parseModuleGraph(buf []byte) (moduleGraph, error) {
+	graph := moduleGraph{}
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("unexpected go mod graph line: %q", scanner.Text())
+		}
+		graph[fields[0]] = append(graph[fields[0]], fields[1])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read go mod graph: %w", err)
+	}
+	return graph, nil
+}
+
+// fetchModuleGraph runs go mod graph for the module in the current directory.
+func fetchModuleGraph() (moduleGraph, error) {
+	buf, err := cmdOutput(config.GoBinary, "mod", "graph")
+	if err != nil {
+		return nil, fmt.Errorf("go mod graph: %w", err)
+	}
+	return parseModuleGraph(buf)
+}
+
+// nodePath strips the version from a go mod graph node.
+func nodePath(node string) string {
+	path, _, _ := strings.Cut(node, "@")
+	return path
+}
+
+// reachablePaths returns the module paths reachable from the given node, excluding itself.
+func (g moduleGraph) reachablePaths(start string) map[string]bool {
+	paths := map[string]bool{}
+	seen := map[string]bool{start: true}
+	queue := []string{start}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for _, next := range g[node] {
+			if seen[next] {
+				continue
+			}
+			seen[next] = true
+			paths[nodePath(next)] = true
+			queue = append(queue, next)
+		}
+	}
+	delete(paths, nodePath(start))
+	return paths
+}
+
+// graphOrderStep records why a module was placed at its position in the processing order.
+type graphOrderStep struct {
+	ModulePath string
+	After      []string // direct dependencies of this module that are processed before it
+	Cycle      bool     // placed in file order because of a dependency cycle
+}
+
+// orderByModuleGraph sorts direct requirements so that modules are processed
+// after the direct dependencies they require (transitively). Ties and cycles
+// keep the go.mod file order.
+func orderByModuleGraph(requires []*modfile.Require, graph moduleGraph) ([]*modfile.Require, []graphOrderStep) {
+	deps := make(map[string][]string, len(requires))
+	for _, r := range requires {
+		reachable := graph.reachablePaths(r.Mod.Path + "@" + r.Mod.Version)
+		for _, other := range requires {
+			if other != r && reachable[other.Mod.Path] {
+				deps[r.Mod.Path] = append(deps[r.Mod.Path], other.Mod.Path)
+			}
+		}
+	}
+
+	placed := map[string]bool{}
+	remaining := slices.Clone(requires)
+	ordered := make([]*modfile.Require, 0, len(requires))
+	steps := make([]graphOrderStep, 0, len(requires))
+	for len(remaining) > 0 {
+		next := slices.IndexFunc(remaining, func(r *modfile.Require) bool {
+			for _, d := range deps[r.Mod.Path] {
+				if !placed[d] {
+					return false
+				}
+			}
+			return true
+		})
+		cycle := next == -1
+		if cycle {
+			next = 0
+		}
+		r := remaining[next]
+		var after []string
+		for _, d := range deps[r.Mod.Path] {
+			if placed[d] {
+				after = append(after, d)
+			}
+		}
+		steps = append(steps, graphOrderStep{ModulePath: r.Mod.Path, After: after, Cycle: cycle})
+		ordered = append(ordered, r)
+		placed[r.Mod.Path] = true
+		remaining = slices.Delete(remaining, next, next+1)
+	}
+	return ordered, steps
+}
+
+// printGraphOrder writes the chosen processing order and the reasoning behind it.
+func printGraphOrder(steps []graphOrderStep) {
+	out.Println("processing order from module graph:")
+	for i, s := range steps {
+		reason := "no direct dependencies among bumped modules"
+		if len(s.After) > 0 {
+			reason = "requires " + strings.Join(s.After, ", ")
+		}
+		if s.Cycle {
+			reason += " (dependency cycle, kept go.mod order)"
+		}
+		out.Println(fmt.Sprintf("%d. %s: %s", i+1, s.ModulePath, reason))
+	}
+}
+
+// graphOrderRequires returns the requirements to order: the direct ones, and
+// with -security the indirect ones too, as security mode bumps them.
+func graphOrderRequires(requires []*modfile.Require) []*modfile.Require {
+	if config.Security {
+		return requires
+	}
+	return slices.DeleteFunc(slices.Clone(requires), func(r *modfile.Require) bool {
+		return r.Indirect
+	})
+}
+
+// graphOrderedDependencies returns the requirements bumped in this mode (see
+// graphOrderRequires) in module graph order. On failure it reports the error
+// and keeps the go.mod file order.
+func graphOrderedDependencies(requires []*modfile.Require) []*modfile.Require {
+	graph, err := fetchModuleGraph()
+	if err != nil {
+		out.Error("failed to order by module graph, keeping go.mod order:", err.Error())
+		return requires
+	}
+	ordered, steps := orderByModuleGraph(graphOrderRequires(requires), graph)
+	if config.Verbose {
+		printGraphOrder(steps)
+	}
+	return ordered
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+func TestOrderByModuleGraph(t *testing.T) {
+	graph, err := parseModuleGraph([]byte(`example.com/main example.com/a@v1.0.0
+example.com/main example.com/b@v1.0.0
+example.com/main example.com/c@v1.0.0
+example.com/main example.com/d@v1.0.0
+example.com/a@v1.0.0 example.com/x@v1.0.0
+example.com/x@v1.0.0 example.com/c@v0.9.0
+example.com/b@v1.0.0 example.com/a@v1.0.0
+example.com/d@v1.0.0 example.com/e@v1.0.0
+example.com/e@v1.0.0 example.com/d@v0.1.0
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	require := func(path string) *modfile.Require {
+		return &modfile.Require{Mod: module.Version{Path: path, Version: "v1.0.0"}}
+	}
+	requires := []*modfile.Require{
+		require("example.com/b"),
+		require("example.com/a"),
+		require("example.com/d"),
+		require("example.com/c"),
+	}
+
+	ordered, steps := orderByModuleGraph(requires, graph)
+
+	var paths []string
+	for _, r := range ordered {
+		paths = append(paths, r.Mod.Path)
+	}
+	want := []string{"example.com/d", "example.com/c", "example.com/a", "example.com/b"}
+	if diff := cmp.Diff(want, paths); diff != "" {
+		t.Errorf("order mismatch (-want +got):\n%s", diff)
+	}
+
+	wantSteps := []graphOrderStep{
+		{ModulePath: "example.com/d"},
+		{ModulePath: "example.com/c"},
+		{ModulePath: "example.com/a", After: []string{"example.com/c"}},
+		{ModulePath: "example.com/b", After: []string{"example.com/a", "example.com/c"}},
+	}
+	if diff := cmp.Diff(wantSteps, steps); diff != "" {
+		t.Errorf("steps mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestOrderByModuleGraphCycle(t *testing.T) {
+	graph, err := parseModuleGraph([]byte(`example.com/a@v1.0.0 example.com/b@v1.0.0
+example.com/b@v1.0.0 example.com/a@v1.0.0
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	requires := []*modfile.Require{
+		{Mod: module.Version{Path: "example.com/a", Version: "v1.0.0"}},
+		{Mod: module.Version{Path: "example.com/b", Version: "v1.0.0"}},
+	}
+
+	ordered, steps := orderByModuleGraph(requires, graph)
+	if ordered[0].Mod.Path != "example.com/a" || !steps[0].Cycle {
+		t.Errorf("expected cycle to keep file order, got %+v", steps)
+	}
+	if steps[1].Cycle || len(steps[1].After) != 1 {
+		t.Errorf("expected b after a, got %+v", steps[1])
+	}
+}
+
+func TestGraphOrderSecurityKeepsIndirect(t *testing.T) {
+	graph, err := parseModuleGraph([]byte(`example.com/main example.com/a@v1.0.0
+example.com/main example.com/v@v1.0.0
+example.com/a@v1.0.0 example.com/v@v1.0.0
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	requires := []*modfile.Require{
+		{Mod: module.Version{Path: "example.com/a", Version: "v1.0.0"}},
+		{Mod: module.Version{Path: "example.com/v", Version: "v1.0.0"}, Indirect: true},
+	}
+	paths := func() []string {
+		ordered, _ := orderByModuleGraph(graphOrderRequires(requires), graph)
+		var paths []string
+		for _, r := range ordered {
+			paths = append(paths, r.Mod.Path)
+		}
+		return paths
+	}
+
+	config = &AppConfig{}
+	if diff := cmp.Diff([]string{"example.com/a"}, paths()); diff != "" {
+		t.Errorf("order mismatch (-want +got):\n%s", diff)
+	}
+	config.Security = true
+	if diff := cmp.Diff([]string{"example.com/v", "example.com/a"}, paths()); diff != "" {
+		t.Errorf("-security order mismatch (-want +got):\n%s", diff)
+	}
+}
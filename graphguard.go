@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"golang.org/x/mod/module"
+)
+
+// graphState is the module graph and go.sum of a workspace at one point.
+type graphState struct {
+	nodes map[string]bool // path@version of every module in the graph
+	sum   map[string]bool // go.sum lines
+}
+
+// paths returns the module paths in the graph.
+func (s graphState) paths() map[string]bool {
+	paths := map[string]bool{}
+	for node := range s.nodes {
+		paths[nodePath(node)] = true
+	}
+	return paths
+}
+
+// graphNodes returns the module nodes of a graph, leaving out the main module
+// and the go and toolchain pseudo-modules.
+func graphNodes(graph moduleGraph) map[string]bool {
+	nodes := map[string]bool{}
+	add := func(node string) {
+		if path, version, ok := strings.Cut(node, "@"); ok && path != "go" && path != "toolchain" && version != "" {
+			nodes[node] = true
+		}
+	}
+	for from, to := range graph {
+		add(from)
+		for _, node := range to {
+			add(node)
+		}
+	}
+	return nodes
+}
+
+// readGraphState runs go mod graph in the workspace and reads its go.sum.
+func readGraphState(ws *workspace) (graphState, error) {
+	buf, err := ws.output(config.GoBinary, "mod", "graph")
+	if err != nil {
+		return graphState{}, fmt.Errorf("go mod graph: %w", err)
+	}
+	graph, err := parseModuleGraph(buf)
+	if err != nil {
+		return graphState{}, err
+	}
+	state := graphState{nodes: graphNodes(graph), sum: map[string]bool{}}
+	sum, err := os.ReadFile(goSumPath(ws))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return graphState{}, err
+	}
+	for _, line := range bytes.Split(sum, []byte("\n")) {
+		if line := strings.TrimSpace(string(line)); line != "" {
+			state.sum[line] = true
+		}
+	}
+	return state, nil
+}
+
+// goSumPath returns the go.sum next to the go.mod of the workspace.
+func goSumPath(ws *workspace) string {
+	return strings.TrimSuffix(ws.GoModDst, ".mod") + ".sum"
+}
+
+// graphDelta is what a bump changes in the module graph and go.sum.
+type graphDelta struct {
+	added, removed       []string         // module paths
+	sumAdded, sumRemoved int              // go.sum lines
+	newNodes             []module.Version // path@version nodes not in the graph before
+}
+
+// diffGraphStates compares the graph before and after a bump.
+func diffGraphStates(before, after graphState) graphDelta {
+	var d graphDelta
+	beforePaths, afterPaths := before.paths(), after.paths()
+	for path := range afterPaths {
+		if !beforePaths[path] {
+			d.added = append(d.added, path)
+		}
+	}
+	for path := range beforePaths {
+		if !afterPaths[path] {
+			d.removed = append(d.removed, path)
+		}
+	}
+	for node := range after.nodes {
+		if !before.nodes[node] {
+			path, version, _ := strings.Cut(node, "@")
+			d.newNodes = append(d.newNodes, module.Version{Path: path, Version: version})
+		}
+	}
+	for line := range after.sum {
+		if !before.sum[line] {
+			d.sumAdded++
+		}
+	}
+	for line := range before.sum {
+		if !after.sum[line] {
+			d.sumRemoved++
+		}
+	}
+	slices.Sort(d.added)
+	slices.Sort(d.removed)
+	slices.SortFunc(d.newNodes, func(a, b module.Version) int {
+		return strings.Compare(a.String(), b.String())
+	})
+	return d
+}
+
+// graphViolation returns why a graph change is not acceptable: more new
+// modules than -max-new-modules, or a module or version matching -deny-module
+// entering the graph. It returns "" when the change is acceptable.
+func graphViolation(d graphDelta) string {
+	if config.MaxNewModules >= 0 && len(d.added) > config.MaxNewModules {
+		return fmt.Sprintf("upgrade adds %d modules to the graph, more than %d", len(d.added), config.MaxNewModules)
+	}
+	for _, node := range d.newNodes {
+		if ok, sel := config.DenySelectors.match(node); ok {
+			return fmt.Sprintf("upgrade adds denied module %s (by %s)", node, sel)
+		}
+	}
+	return ""
+}
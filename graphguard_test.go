@@ -0,0 +1,98 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDiffGraphStates(t *testing.T) {
+	graph, err := parseModuleGraph([]byte(`example.com/app example.com/a@v1.0.0
+example.com/app go@1.22
+example.com/a@v1.0.0 example.com/b@v1.0.0
+example.com/a@v1.0.0 example.com/gone@v0.1.0
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := graphState{nodes: graphNodes(graph), sum: map[string]bool{"a": true, "b": true}}
+	graph, err = parseModuleGraph([]byte(`example.com/app example.com/a@v1.1.0
+example.com/app go@1.22
+example.com/a@v1.1.0 example.com/b@v1.0.0
+example.com/a@v1.1.0 example.com/c@v1.0.0
+example.com/c@v1.0.0 example.com/d@v0.3.0
+example.com/c@v1.0.0 toolchain@go1.22.1
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	after := graphState{nodes: graphNodes(graph), sum: map[string]bool{"a": true, "c": true, "d": true}}
+
+	d := diffGraphStates(before, after)
+	if diff := cmp.Diff([]string{"example.com/c", "example.com/d"}, d.added); diff != "" {
+		t.Errorf("added (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"example.com/gone"}, d.removed); diff != "" {
+		t.Errorf("removed (-want +got):\n%s", diff)
+	}
+	if d.sumAdded != 2 || d.sumRemoved != 1 {
+		t.Errorf("go.sum delta +%d -%d, want +2 -1", d.sumAdded, d.sumRemoved)
+	}
+
+	tests := []struct {
+		name string
+		max  int
+		deny []string
+		want string
+	}{
+		{"no limits", -1, nil, ""},
+		{"within limit", 2, nil, ""},
+		{"too many", 1, nil, "upgrade adds 2 modules to the graph, more than 1"},
+		{"denied", -1, []string{"example.com/x", "example.com/d"}, "upgrade adds denied module example.com/d@v0.3.0 (by example.com/d)"},
+		{"denied version", -1, []string{"example.com/a@<v1.1.0"}, ""},
+		{"denied new version", -1, []string{"example.com/a@>=v1.1.0"}, "upgrade adds denied module example.com/a@v1.1.0 (by example.com/a@>=v1.1.0)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config = &AppConfig{MaxNewModules: tt.max, DenyModules: tt.deny}
+			if err := validateSelectors(); err != nil {
+				t.Fatal(err)
+			}
+			if got := graphViolation(d); got != tt.want {
+				t.Errorf("graphViolation = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadGraphState(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":     "module example.com/app\n\ngo 1.22\n\nrequire example.com/lib v1.0.0\n\nreplace example.com/lib => ./lib\n",
+		"go.sum":     "example.com/other v1.0.0 h1:abc=\n\n",
+		"lib/go.mod": "module example.com/lib\n\ngo 1.22\n",
+	}
+	for name, content := range files {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	config = &AppConfig{GoBinary: "go"}
+	ws := &workspace{Dir: dir, GoModDst: filepath.Join(dir, "go.mod"), Out: &OutputNone{}}
+	state, err := readGraphState(ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(map[string]bool{"example.com/lib@v1.0.0": true}, state.nodes); diff != "" {
+		t.Errorf("nodes (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string]bool{"example.com/other v1.0.0 h1:abc=": true}, state.sum); diff != "" {
+		t.Errorf("sum (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// moduleGroup returns the configured group of modulePath, or "" when it is not grouped.
+func moduleGroup(modulePath string) string {
+	for _, name := range sortedKeys(config.Groups) {
+		if slices.Contains(config.Groups[name], modulePath) {
+			return name
+		}
+	}
+	return ""
+}
+
+// groupRequires moves the members of each group right after the first member
+// so a group is processed, and committed, as one run.
+func groupRequires(requires []*modfile.Require) []*modfile.Require {
+	result := make([]*modfile.Require, 0, len(requires))
+	done := map[string]bool{}
+	for _, r := range requires {
+		group := moduleGroup(r.Mod.Path)
+		if group == "" {
+			result = append(result, r)
+			continue
+		}
+		if done[group] {
+			continue
+		}
+		done[group] = true
+		for _, m := range requires {
+			if moduleGroup(m.Mod.Path) == group {
+				result = append(result, m)
+			}
+		}
+	}
+	return result
+}
+
+// bumpCommitter commits dependency bumps in the main tree: one commit per
+// module, or one commit per group after its last member was processed.
+type bumpCommitter struct {
+	requires []*modfile.Require // processing order, see groupRequires
+	bumps    []string           // uncommitted bumps of the current group
+}
+
+// lastOfGroup reports whether requires[i] is the last member of group in a row.
+func (c *bumpCommitter) lastOfGroup(i int, group string) bool {
+	return i+1 == len(c.requires) || moduleGroup(c.requires[i+1].Mod.Path) != group
+}
+
+// finish commits or resets after requires[i] was processed.
+func (c *bumpCommitter) finish(i int, upgradeSuccess bool, versionAfter string) {
+	r := c.requires[i]
+	group := moduleGroup(r.Mod.Path)
+	if group == "" {
+		if !upgradeSuccess {
+			if err := gitResetHardHEAD(); err != nil {
+				out.Error("git reset/clean failed:", err.Error())
+			}
+		} else if versionAfter != r.Mod.Version && gitWorktreeDiffersFromHEAD() {
+			if err := gitCommitDependencyBump(r.Mod.Path, r.Mod.Version, versionAfter); err != nil {
+				out.Error("git commit failed:", err.Error())
+			}
+		}
+		return
+	}
+
+	// A failed member has restored go.mod already; resetting would drop the
+	// bumps of earlier members.
+	if upgradeSuccess && versionAfter != r.Mod.Version {
+		bump := fmt.Sprintf("%s %s => %s", r.Mod.Path, r.Mod.Version, versionAfter)
+		if fixes := advisoriesFixed(r.Mod.Path, r.Mod.Version, versionAfter); len(fixes) > 0 {
+			bump += ", fixes " + advisoryList(fixes, Advisory.String)
+		}
+		for _, c := range apiChangeList(r.Mod.Path, r.Mod.Version, versionAfter) {
+			bump += "\n  incompatible: " + c
+		}
+		for _, issue := range cachedLicenseIssues(r.Mod.Path, r.Mod.Version, versionAfter) {
+			bump += "\n  license warning: " + issue
+		}
+		c.bumps = append(c.bumps, bump)
+	}
+	if !c.lastOfGroup(i, group) {
+		return
+	}
+	bumps := c.bumps
+	c.bumps = nil
+	if len(bumps) == 0 || !gitWorktreeDiffersFromHEAD() {
+		if err := gitResetHardHEAD(); err != nil {
+			out.Error("git reset/clean failed:", err.Error())
+		}
+		return
+	}
+	msg := fmt.Sprintf("%s: update %s group\n\n%s\n", config.CommitPrefix, group, strings.Join(bumps, "\n"))
+	if err := gitCommitGoMod(msg); err != nil {
+		out.Error("git commit failed:", err.Error())
+	}
+}
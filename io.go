@@ -1,40 +1,57 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
-	"os"
+	"io"
 	"os/exec"
 	"strings"
 )
 
 const (
-	ERR_READ  = 2
-	ERR_WRITE = 3
-	ERR_PARSE = 4
-	ERR_CMD   = 5
-	ERR_GIT   = 6
+	ERR_READ      = 2
+	ERR_WRITE     = 3
+	ERR_PARSE     = 4
+	ERR_CMD       = 5
+	ERR_GIT       = 6
+	ERR_ARGS      = 7
+	ERR_TOOLCHAIN = 8
 )
 
-// cmd runs a subprocess; when verbose, echoes the command and streams stdout/stderr to out
-// (intended for go get and -exec inside a preformatted block).
-func cmd(name string, args ...string) error {
-	return runCmd(name, args, true)
+// cmdIn runs a subprocess in dir (empty for the current directory); when verbose, echoes the
+// command and streams stdout/stderr to o (intended for go get and -exec inside a preformatted block).
+func cmdIn(o Output, dir, name string, args ...string) error {
+	return runCmd(o, dir, name, args, true)
 }
 
 // cmdQuiet runs a subprocess without writing to out (e.g. go mod tidy before git commit).
 func cmdQuiet(name string, args ...string) error {
-	return runCmd(name, args, false)
+	return runCmd(out, "", name, args, false)
 }
 
-func runCmd(name string, args []string, logOutput bool) error {
+// cmdOutput runs a subprocess and returns its stdout without writing to out (e.g. go mod graph).
+func cmdOutput(name string, args ...string) ([]byte, error) {
+	return cmdOutputIn("", name, args...)
+}
+
+// cmdOutputIn is cmdOutput in dir (empty for the current directory).
+func cmdOutputIn(dir, name string, args ...string) ([]byte, error) {
+	c := exec.Command(name, args...)
+	c.Dir = dir
+	c.Env = subprocessEnv()
+	return c.Output()
+}
+
+func runCmd(o Output, dir, name string, args []string, logOutput bool) error {
 	if logOutput && config.Verbose {
-		out.Println(name, strings.Join(args, " "))
+		o.Println(name, strings.Join(args, " "))
 	}
 	c := exec.Command(name, args...)
-	c.Env = os.Environ()
+	c.Dir = dir
+	c.Env = subprocessEnv()
 	if logOutput && config.Verbose {
-		c.Stdout = out
-		c.Stderr = out
+		c.Stdout = o
+		c.Stderr = o
 	}
 	if err := c.Run(); err != nil {
 		return err
@@ -44,17 +61,41 @@ func runCmd(name string, args []string, logOutput bool) error {
 
 var ErrCmd = fmt.Errorf("command error")
 
-func cmds(str string) error {
+// cmdsOutputIn is cmdsIn returning the combined stdout and stderr, which are
+// still streamed to o when verbose.
+func cmdsOutputIn(o Output, dir, str string) ([]byte, error) {
+	parts := strings.Fields(str)
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("%w: no command", ErrCmd)
+	}
+	if config.Verbose {
+		o.Println(parts[0], strings.Join(parts[1:], " "))
+	}
+	var buf bytes.Buffer
+	c := exec.Command(parts[0], parts[1:]...)
+	c.Dir = dir
+	c.Env = subprocessEnv()
+	c.Stdout = &buf
+	if config.Verbose {
+		c.Stdout = io.MultiWriter(&buf, o)
+	}
+	c.Stderr = c.Stdout
+	err := c.Run()
+	return buf.Bytes(), err
+}
+
+// cmdsIn splits str into fields (no shell) and runs it like cmdIn.
+func cmdsIn(o Output, dir, str string) error {
 	parts := strings.Fields(str)
 	if len(parts) == 0 {
 		return fmt.Errorf("%w: no command", ErrCmd)
 	}
 
 	if len(parts) == 1 {
-		return cmd(parts[0])
+		return cmdIn(o, dir, parts[0])
 	}
 
 	p1 := parts[0]
 	p2 := parts[1:]
-	return cmd(p1, p2...)
+	return cmdIn(o, dir, p1, p2...)
 }
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+const (
+	// licenseNone is reported for modules without a license file.
+	licenseNone = "none"
+	// licenseUnknown is reported for license files the matcher does not recognize.
+	licenseUnknown = "unknown"
+)
+
+// licenseMatchers identify licenses by phrases of their text, lowercase with
+// collapsed whitespace. The first match wins, so licenses quoting others
+// (the LGPL mentions the GPL) come first.
+var licenseMatchers = []struct {
+	spdx    string
+	phrases []string
+}{
+	{"AGPL-3.0", []string{"gnu affero general public license", "version 3"}},
+	{"LGPL-3.0", []string{"gnu lesser general public license", "version 3"}},
+	{"LGPL-2.1", []string{"gnu lesser general public license", "version 2.1"}},
+	{"LGPL-2.0", []string{"gnu library general public license", "version 2"}},
+	{"GPL-3.0", []string{"gnu general public license", "version 3"}},
+	{"GPL-2.0", []string{"gnu general public license", "version 2"}},
+	{"MPL-2.0", []string{"mozilla public license", "2.0"}},
+	{"EPL-2.0", []string{"eclipse public license - v 2.0"}},
+	{"Apache-2.0", []string{"apache license", "version 2.0"}},
+	{"BSL-1.0", []string{"boost software license - version 1.0"}},
+	{"Unlicense", []string{"this is free and unencumbered software released into the public domain"}},
+	{"CC0-1.0", []string{"cc0 1.0 universal"}},
+	{"MIT", []string{"permission is hereby granted, free of charge, to any person obtaining a copy"}},
+	{"ISC", []string{"permission to use, copy, modify, and", "distribute this software for any purpose with or without fee is hereby granted"}},
+	{"BSD-3-Clause", []string{"redistribution and use in source and binary forms", "neither the name"}},
+	{"BSD-2-Clause", []string{"redistribution and use in source and binary forms"}},
+}
+
+// classifyLicense returns the SPDX identifier of a license text, or licenseUnknown.
+func classifyLicense(text string) string {
+	text = strings.ToLower(strings.Join(strings.Fields(text), " "))
+	for _, m := range licenseMatchers {
+		if !slices.ContainsFunc(m.phrases, func(p string) bool { return !strings.Contains(text, p) }) {
+			return m.spdx
+		}
+	}
+	return licenseUnknown
+}
+
+// isLicenseFile reports whether a file in the module root holds a license.
+func isLicenseFile(name string) bool {
+	base := strings.ToUpper(strings.TrimSuffix(name, path.Ext(name)))
+	for _, prefix := range []string{"LICENSE", "LICENCE", "COPYING", "UNLICENSE"} {
+		if strings.HasPrefix(base, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// moduleLicenses classifies the license files in the root of a module zip and
+// returns their sorted SPDX identifiers, or licenseNone.
+func moduleLicenses(modulePath, version string, zipData []byte) ([]string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(zipData), int64(len(zipData)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open module zip: %w", err)
+	}
+	prefix := modulePath + "@" + version + "/"
+	var licenses []string
+	for _, f := range zr.File {
+		name, ok := strings.CutPrefix(f.Name, prefix)
+		if !ok || strings.Contains(name, "/") || !isLicenseFile(name) {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
+		}
+		text, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
+		}
+		licenses = append(licenses, classifyLicense(string(text)))
+	}
+	if len(licenses) == 0 {
+		return []string{licenseNone}, nil
+	}
+	slices.Sort(licenses)
+	return slices.Compact(licenses), nil
+}
+
+// validateLicenseCheck checks the -license-check value.
+func validateLicenseCheck() error {
+	return validateCheckMode("license-check", config.LicenseCheck)
+}
+
+// licenseAllowed reports whether every license is in -license-allow; an empty
+// allow-list allows everything.
+func licenseAllowed(licenses []string) bool {
+	if len(config.LicenseAllow) == 0 {
+		return true
+	}
+	for _, l := range licenses {
+		if !slices.Contains(config.LicenseAllow, l) {
+			return false
+		}
+	}
+	return true
+}
+
+// licenseCache keeps the licenses of module versions and the issues found
+// for bumps, so that the summary and commit messages do not download zips again.
+var licenseCache = struct {
+	sync.Mutex
+	licenses map[string][]string
+	issues   map[string][]string
+}{licenses: map[string][]string{}, issues: map[string][]string{}}
+
+// fetchModuleLicenses downloads the zip of a module version and classifies its licenses.
+func fetchModuleLicenses(proxy *GoProxy, modulePath, version string) ([]string, error) {
+	key := modulePath + "@" + version
+	licenseCache.Lock()
+	licenses, ok := licenseCache.licenses[key]
+	licenseCache.Unlock()
+	if ok {
+		return licenses, nil
+	}
+	data, err := proxy.FetchZip(modulePath, version)
+	if err != nil {
+		return nil, err
+	}
+	if licenses, err = moduleLicenses(modulePath, version, data); err != nil {
+		return nil, err
+	}
+	licenseCache.Lock()
+	licenseCache.licenses[key] = licenses
+	licenseCache.Unlock()
+	return licenses, nil
+}
+
+// addedModules returns the modules a bump of modulePath adds to the build:
+// the paths new in the module graph, at the highest version the graph has,
+// when the graph delta is known, otherwise the requirements newMod adds to okMod.
+func addedModules(modulePath string, okMod, newMod *modfile.File, graph *graphDelta) []module.Version {
+	var result []module.Version
+	if graph == nil {
+		for _, c := range diffRequires(okMod, newMod, modulePath) {
+			if c.VersionBefore == "" && c.VersionAfter != "" {
+				result = append(result, module.Version{Path: c.ModulePath, Version: c.VersionAfter})
+			}
+		}
+		return result
+	}
+	selected := map[string]string{}
+	for _, node := range graph.newNodes {
+		if slices.Contains(graph.added, node.Path) && semver.Compare(node.Version, selected[node.Path]) > 0 {
+			selected[node.Path] = node.Version
+		}
+	}
+	for _, path := range sortedKeys(selected) {
+		result = append(result, module.Version{Path: path, Version: selected[path]})
+	}
+	return result
+}
+
+// licenseIssues returns the license issues of bumping modulePath from before
+// to after, which adds the added modules: a changed license of the module,
+// and licenses outside -license-allow of the module and of the added modules.
+func licenseIssues(proxy *GoProxy, modulePath, before, after string, added []module.Version) ([]string, error) {
+	key := modulePath + "@" + before + ".." + after
+	licenseCache.Lock()
+	issues, ok := licenseCache.issues[key]
+	licenseCache.Unlock()
+	if ok {
+		return issues, nil
+	}
+
+	beforeLicenses, err := fetchModuleLicenses(proxy, modulePath, before)
+	if err != nil {
+		return nil, err
+	}
+	afterLicenses, err := fetchModuleLicenses(proxy, modulePath, after)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Equal(beforeLicenses, afterLicenses) {
+		issues = append(issues, fmt.Sprintf("%s license changed %s => %s", modulePath, strings.Join(beforeLicenses, ", "), strings.Join(afterLicenses, ", ")))
+	}
+	if !licenseAllowed(afterLicenses) {
+		issues = append(issues, fmt.Sprintf("%s license %s not allowed", modulePath, strings.Join(afterLicenses, ", ")))
+	}
+	for _, m := range added {
+		licenses, err := fetchModuleLicenses(proxy, m.Path, m.Version)
+		if err != nil {
+			return nil, err
+		}
+		if !licenseAllowed(licenses) {
+			issues = append(issues, fmt.Sprintf("%s added with license %s not allowed", m.Path, strings.Join(licenses, ", ")))
+		}
+	}
+
+	licenseCache.Lock()
+	licenseCache.issues[key] = issues
+	licenseCache.Unlock()
+	return issues, nil
+}
+
+// cachedLicenseIssues returns the issues licenseIssues found for a bump before.
+func cachedLicenseIssues(modulePath, before, after string) []string {
+	licenseCache.Lock()
+	defer licenseCache.Unlock()
+	return licenseCache.issues[modulePath+"@"+before+".."+after]
+}
+
+// checkLicenses looks for license issues of upgrading r to version, which
+// adds the added modules, with -license-check and reports whether the version
+// is acceptable. With block, versions with issues or whose licenses could not
+// be verified are rejected and go.mod is restored to revertTo.
+func checkLicenses(ws *workspace, proxy *GoProxy, r *modfile.Require, version string, revertTo *modfile.File, added []module.Version) ([]string, bool) {
+	if config.LicenseCheck == checkOff || version == r.Mod.Version {
+		return nil, true
+	}
+	issues, err := licenseIssues(proxy, r.Mod.Path, r.Mod.Version, version, added)
+	if err != nil {
+		ws.Out.Error("failed to check licenses:", err.Error())
+		if config.LicenseCheck != checkBlock {
+			return nil, true
+		}
+		issues = []string{"license could not be verified: " + err.Error()}
+	}
+	if len(issues) == 0 || config.LicenseCheck != checkBlock {
+		return issues, true
+	}
+	for _, issue := range issues {
+		ws.Out.Println("license issue:", issue)
+	}
+	ws.Out.Error("license issues, reverting go.mod")
+	if err := saveMod(ws.GoModDst, revertTo); err != nil {
+		ws.Out.Error("failed to revert go.mod:", err.Error())
+	}
+	return issues, false
+}
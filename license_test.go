@@ -0,0 +1,171 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+const (
+	mitText = `MIT License
+
+Copyright (c) 2024 Example
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction.`
+	gpl3Text = `                    GNU GENERAL PUBLIC LICENSE
+                       Version 3, 29 June 2007
+
+ Copyright (C) 2007 Free Software Foundation, Inc. <https://fsf.org/>`
+	apacheText = `                                 Apache License
+                           Version 2.0, January 2004
+                        http://www.apache.org/licenses/`
+)
+
+func TestClassifyLicense(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{mitText, "MIT"},
+		{gpl3Text, "GPL-3.0"},
+		{apacheText, "Apache-2.0"},
+		{"GNU LESSER GENERAL PUBLIC LICENSE\nVersion 3, 29 June 2007\n\nThis version of the GNU Lesser General Public License incorporates the terms of version 3 of the GNU General Public License", "LGPL-3.0"},
+		{"GNU AFFERO GENERAL PUBLIC LICENSE\nVersion 3, 19 November 2007", "AGPL-3.0"},
+		{"Mozilla Public License Version 2.0\n==================================", "MPL-2.0"},
+		{"Redistribution and use in source and binary forms, with or without\nmodification, are permitted provided that...\n* Neither the name of Google Inc. nor the names", "BSD-3-Clause"},
+		{"Redistribution and use in source and binary forms, with or without modification, are permitted", "BSD-2-Clause"},
+		{"Permission to use, copy, modify, and/or distribute this software for any purpose with or without fee is hereby granted", "ISC"},
+		{"All rights reserved. Do not copy.", licenseUnknown},
+	}
+	for _, tt := range tests {
+		if got := classifyLicense(tt.text); got != tt.want {
+			t.Errorf("classifyLicense(%.30q) = %s, want %s", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestModuleLicenses(t *testing.T) {
+	data := testModuleZip(t, "example.com/lib@v1.0.0", map[string]string{
+		"LICENSE-MIT":           mitText,
+		"LICENSE-APACHE.txt":    apacheText,
+		"third_party/x/LICENSE": gpl3Text,
+		"lib.go":                "package lib\n",
+	})
+	got, err := moduleLicenses("example.com/lib", "v1.0.0", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"Apache-2.0", "MIT"}, got); diff != "" {
+		t.Errorf("moduleLicenses (-want +got):\n%s", diff)
+	}
+	got, err = moduleLicenses("example.com/lib", "v1.0.0", testModuleZip(t, "example.com/lib@v1.0.0", map[string]string{"lib.go": "package lib\n"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{licenseNone}, got); diff != "" {
+		t.Errorf("moduleLicenses without license (-want +got):\n%s", diff)
+	}
+}
+
+func TestLicenseIssues(t *testing.T) {
+	zips := map[string][]byte{
+		"/example.com/relicensed/@v/v1.0.0.zip": testModuleZip(t, "example.com/relicensed@v1.0.0", map[string]string{"LICENSE": mitText}),
+		"/example.com/relicensed/@v/v1.1.0.zip": testModuleZip(t, "example.com/relicensed@v1.1.0", map[string]string{"COPYING": gpl3Text}),
+		"/example.com/added/@v/v0.1.0.zip":      testModuleZip(t, "example.com/added@v0.1.0", map[string]string{"README.md": "no license"}),
+		"/example.com/allowed/@v/v0.2.0.zip":    testModuleZip(t, "example.com/allowed@v0.2.0", map[string]string{"LICENSE": apacheText}),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := zips[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	okMod, err := modfile.Parse("go.mod", []byte("module example.com/app\n\ngo 1.22\n\nrequire example.com/relicensed v1.0.0\n"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newMod, err := modfile.Parse("go.mod", []byte("module example.com/app\n\ngo 1.22\n\nrequire (\n\texample.com/relicensed v1.1.0\n\texample.com/added v0.1.0\n\texample.com/allowed v0.2.0\n)\n"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config = &AppConfig{LicenseCheck: checkWarn, LicenseAllow: []string{"MIT", "Apache-2.0"}}
+	issues, err := licenseIssues(NewGoProxy(server.URL), "example.com/relicensed", "v1.0.0", "v1.1.0", addedModules("example.com/relicensed", okMod, newMod, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"example.com/relicensed license changed MIT => GPL-3.0",
+		"example.com/relicensed license GPL-3.0 not allowed",
+		"example.com/added added with license none not allowed",
+	}
+	if diff := cmp.Diff(want, issues); diff != "" {
+		t.Errorf("licenseIssues (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(want, cachedLicenseIssues("example.com/relicensed", "v1.0.0", "v1.1.0")); diff != "" {
+		t.Errorf("cachedLicenseIssues (-want +got):\n%s", diff)
+	}
+}
+
+func TestAddedModulesFromGraph(t *testing.T) {
+	d := diffGraphStates(
+		graphState{nodes: map[string]bool{"example.com/app": true, "example.com/dep@v1.0.0": true}},
+		graphState{nodes: map[string]bool{
+			"example.com/app":               true,
+			"example.com/dep@v1.1.0":        true,
+			"example.com/transitive@v0.1.0": true,
+			"example.com/transitive@v0.2.0": true,
+			"example.com/deeper@v1.0.0":     true,
+		}},
+	)
+	// The transitive modules are not in go.mod, only in the module graph.
+	mod, err := modfile.Parse("go.mod", []byte("module example.com/app\n\ngo 1.22\n\nrequire example.com/dep v1.0.0\n"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []module.Version{
+		{Path: "example.com/deeper", Version: "v1.0.0"},
+		{Path: "example.com/transitive", Version: "v0.2.0"},
+	}
+	if diff := cmp.Diff(want, addedModules("example.com/dep", mod, mod, &d)); diff != "" {
+		t.Errorf("addedModules (-want +got):\n%s", diff)
+	}
+}
+
+func TestCheckLicensesUnverified(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	dir := t.TempDir()
+	revertTo, err := modfile.Parse("go.mod", []byte("module example.com/app\n\ngo 1.22\n\nrequire example.com/unverified v1.0.0\n"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws := &workspace{Dir: dir, GoModDst: filepath.Join(dir, "go.mod"), Out: &OutputNone{}}
+	r := revertTo.Require[0]
+
+	config = &AppConfig{LicenseCheck: checkWarn}
+	if issues, ok := checkLicenses(ws, NewGoProxy(server.URL), r, "v1.1.0", revertTo, nil); !ok || issues != nil {
+		t.Errorf("warn: checkLicenses = %v, %v, want nil, true", issues, ok)
+	}
+
+	config = &AppConfig{LicenseCheck: checkBlock}
+	issues, ok := checkLicenses(ws, NewGoProxy(server.URL), r, "v1.1.0", revertTo, nil)
+	if ok || len(issues) != 1 || !strings.HasPrefix(issues[0], "license could not be verified: ") {
+		t.Errorf("block: checkLicenses = %v, %v, want a license could not be verified issue", issues, ok)
+	}
+	if _, err := os.Stat(ws.GoModDst); err != nil {
+		t.Errorf("go.mod not restored: %v", err)
+	}
+}
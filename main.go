@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"runtime/debug"
+	"strings"
 )
 
 var (
@@ -31,8 +32,30 @@ func main() {
 		out = &OutputNone{}
 	}
 
-	if err := errIfUnsafeGitWorktree(); err != nil {
-		out.Fatal(err.Error(), ERR_GIT)
+	if config.Command == commandConfig {
+		os.Exit(runConfigCommand())
+	}
+	if len(config.ConfigErrors) > 0 {
+		msgs := make([]string, len(config.ConfigErrors))
+		for i, e := range config.ConfigErrors {
+			msgs[i] = e.Error()
+		}
+		out.Fatal(strings.Join(msgs, "\n"), ERR_ARGS)
+	}
+
+	if err := validateSelectors(); err != nil {
+		out.Fatal(err.Error(), ERR_ARGS)
+	}
+	for _, validate := range []func() error{validatePolicies, validateUpdate, validateBaseline, validateExecRetries, validateLicenseCheck, validateCgoCheck, validateBench} {
+		if err := validate(); err != nil {
+			out.Fatal(err.Error(), ERR_ARGS)
+		}
+	}
+
+	if config.Command == "" {
+		if err := errIfUnsafeGitWorktree(); err != nil {
+			out.Fatal(err.Error(), ERR_GIT)
+		}
 	}
 
 	out.Begin()
@@ -42,16 +65,65 @@ func main() {
 	if err != nil {
 		out.Fatal(err.Error(), ERR_PARSE)
 	}
+	annotations, errs := parseAnnotations(config.GoModSrc, original)
+	if len(errs) > 0 {
+		msgs := make([]string, len(errs))
+		for i, e := range errs {
+			msgs[i] = e.Error()
+		}
+		out.Fatal(strings.Join(msgs, "\n"), ERR_PARSE)
+	}
+	applyAnnotations(annotations)
+	if config.Security && config.VulnDB == "" {
+		out.Fatal("-security requires -vulndb", ERR_ARGS)
+	}
+	if config.VulnDB != "" {
+		if vulnDB, err = loadVulnDatabase(config.VulnDB); err != nil {
+			out.Fatal(err.Error(), ERR_READ)
+		}
+	}
+	if (config.Reachability || config.ReachableOnly) && config.VulnDB == "" {
+		out.Fatal("-reachability requires -vulndb", ERR_ARGS)
+	}
+	if config.ReachableOnly && !config.Security {
+		out.Fatal("-reachable-only requires -security", ERR_ARGS)
+	}
+	if config.Reachability || config.ReachableOnly {
+		if err := analyzeReachability(original); err != nil {
+			if config.ReachableOnly {
+				out.Fatal("reachability analysis: "+err.Error(), ERR_CMD)
+			}
+			out.Error("warning: reachability analysis:", err.Error())
+			vulnDB.reachable = nil
+		}
+	}
+	if err := validateTargetGo(original); err != nil {
+		out.Fatal(err.Error(), ERR_ARGS)
+	}
 
-	defer func() {
+	if config.Command != "" {
+		runReport(original)
+		return
+	}
+
+	if err := pinToolchain(original); err != nil {
+		out.Fatal(err.Error(), ERR_TOOLCHAIN)
+	}
+
+	restore := func() {
 		if config.DryRun {
 			if err := saveMod(config.GoModDst, original); err != nil {
 				out.Fatal(err.Error(), ERR_WRITE)
 			}
 		}
-	}()
+	}
+	defer restore()
 
-	results := process(original)
+	results, err := process(original)
+	if err != nil {
+		restore()
+		out.Fatal(err.Error(), ERR_CMD)
+	}
 
 	out.PrintSummary(results)
 	if config.Changelog && !perDependencyGitEnabled() {
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"go/version"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// CompatibilityMatrix lists, per direct dependency, the highest version that
+// each Go release from the pinned one up to the newest required one can use.
+type CompatibilityMatrix struct {
+	PinnedGo   string                `json:"pinned_go"`
+	GoVersions []string              `json:"go_versions"` // Go language versions, e.g. 1.23
+	Modules    []ModuleCompatibility `json:"modules"`
+}
+
+// ModuleCompatibility is one row of the compatibility matrix.
+type ModuleCompatibility struct {
+	ModulePath string            `json:"module"`
+	Version    string            `json:"version"`
+	Compatible map[string]string `json:"compatible"` // Go language version => highest usable module version
+	Error      string            `json:"error,omitempty"`
+}
+
+// versionGo is a module version together with the go directive of its go.mod.
+type versionGo struct {
+	Version string
+	Go      string // go/version release name, empty when the go.mod has no go directive
+}
+
+// fetchVersionsGo returns the versions newer than r in descending order with their go directives.
+func fetchVersionsGo(proxy *GoProxy, r *modfile.Require) ([]versionGo, error) {
+	versions, err := proxy.FetchVersions(r.Mod.Path, r.Mod.Version)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]versionGo, 0, len(versions))
+	for _, v := range versions {
+		mod, err := proxy.FetchModFile(r.Mod.Path, v.Version)
+		if err != nil {
+			return nil, err
+		}
+		vg := versionGo{Version: v.Version}
+		if mod.Go != nil {
+			vg.Go = goRelease(mod.Go.Version)
+		}
+		result = append(result, vg)
+	}
+	return result, nil
+}
+
+// goMinor returns the minor number of a Go release or language version (go1.23.1 => 23).
+func goMinor(v string) int {
+	minor, _, _ := strings.Cut(strings.TrimPrefix(version.Lang(v), "go1."), ".")
+	n, _ := strconv.Atoi(minor)
+	return n
+}
+
+// goSatisfies reports whether a go directive (as a release name) can be built by
+// limit: a release such as go1.22.0, or a language version such as go1.23
+// standing for any of its patch releases.
+func goSatisfies(goDirective, limit string) bool {
+	if goDirective == "" {
+		return true
+	}
+	if version.Lang(limit) == limit {
+		return version.Compare(version.Lang(goDirective), limit) <= 0
+	}
+	return version.Compare(goDirective, limit) <= 0
+}
+
+// highestCompatible returns the newest version whose go directive satisfies limit, else current.
+func highestCompatible(versions []versionGo, limit, current string) string {
+	for _, v := range versions {
+		if goSatisfies(v.Go, limit) {
+			return v.Version
+		}
+	}
+	return current
+}
+
+// compatibilityMatrix builds the matrix for the selected direct dependencies.
+// The column of the pinned Go uses the pinned release exactly, later columns
+// any patch release of that Go version.
+func compatibilityMatrix(original *modfile.File) CompatibilityMatrix {
+	proxy := NewGoProxy(config.ModuleProxy)
+	pinned := pinnedGo(original)
+	matrix := CompatibilityMatrix{PinnedGo: pinned}
+
+	type row struct {
+		r        *modfile.Require
+		versions []versionGo
+		err      error
+	}
+	var rows []row
+	newest := goMinor(pinned)
+	for _, r := range selectedDependencies(original) {
+		if r.Indirect {
+			continue
+		}
+		versions, err := fetchVersionsGo(proxy, r)
+		for _, v := range versions {
+			if v.Go != "" && goMinor(v.Go) > newest {
+				newest = goMinor(v.Go)
+			}
+		}
+		rows = append(rows, row{r: r, versions: versions, err: err})
+	}
+
+	limits := map[string]string{}
+	for minor := goMinor(pinned); pinned != "" && minor <= newest; minor++ {
+		lang := "1." + strconv.Itoa(minor)
+		matrix.GoVersions = append(matrix.GoVersions, lang)
+		limits[lang] = "go" + lang
+	}
+	if len(matrix.GoVersions) > 0 {
+		limits[matrix.GoVersions[0]] = pinned
+	}
+
+	for _, row := range rows {
+		mc := ModuleCompatibility{
+			ModulePath: row.r.Mod.Path,
+			Version:    row.r.Mod.Version,
+			Compatible: map[string]string{},
+		}
+		if row.err != nil {
+			mc.Error = row.err.Error()
+		} else {
+			for _, lang := range matrix.GoVersions {
+				mc.Compatible[lang] = highestCompatible(row.versions, limits[lang], row.r.Mod.Version)
+			}
+		}
+		matrix.Modules = append(matrix.Modules, mc)
+	}
+	return matrix
+}
@@ -0,0 +1,37 @@
+package main
+
+import "testing"
+
+func TestHighestCompatible(t *testing.T) {
+	versions := []versionGo{
+		{Version: "v1.4.0", Go: "go1.25.0"},
+		{Version: "v1.3.0", Go: "go1.24.3"},
+		{Version: "v1.2.0", Go: "go1.23.1"},
+		{Version: "v1.1.0", Go: ""},
+	}
+	tests := []struct {
+		limit, want string
+	}{
+		{"go1.23.0", "v1.1.0"},
+		{"go1.23.1", "v1.2.0"},
+		{"go1.23", "v1.2.0"},
+		{"go1.24", "v1.3.0"},
+		{"go1.26", "v1.4.0"},
+	}
+	for _, tt := range tests {
+		if got := highestCompatible(versions, tt.limit, "v1.0.0"); got != tt.want {
+			t.Errorf("highestCompatible(%s) = %s, want %s", tt.limit, got, tt.want)
+		}
+	}
+	if got := highestCompatible(versions[:1], "go1.22", "v1.0.0"); got != "v1.0.0" {
+		t.Errorf("expected current version when nothing is compatible, got %s", got)
+	}
+}
+
+func TestGoMinor(t *testing.T) {
+	for v, want := range map[string]int{"go1.23.1": 23, "go1.24": 24, "go1.21rc2": 21} {
+		if got := goMinor(v); got != want {
+			t.Errorf("goMinor(%s) = %d, want %d", v, got, want)
+		}
+	}
+}
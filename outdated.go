@@ -0,0 +1,82 @@
+package main
+
+import (
+	"go/version"
+
+	"golang.org/x/mod/modfile"
+)
+
+// OutdatedModule is one row of the outdated report.
+type OutdatedModule struct {
+	ModulePath       string `json:"module"`
+	Version          string `json:"version"`
+	Latest           string `json:"latest"`            // newest version on the module proxy
+	LatestGo         string `json:"latest_go"`         // go directive of Latest
+	LatestCompatible string `json:"latest_compatible"` // newest version whose go directive the pinned Go satisfies
+	Error            string `json:"error,omitempty"`
+}
+
+// pinnedGo returns the Go release dependencies must be compatible with:
+// the pinned toolchain, else the first release of the go directive.
+func pinnedGo(mod *modfile.File) string {
+	if want := wantToolchain(mod); want != "" {
+		return want
+	}
+	if mod.Go == nil {
+		return ""
+	}
+	return goRelease(mod.Go.Version)
+}
+
+// goCompatible reports whether a module's go.mod does not require a Go newer than the pinned release.
+func goCompatible(mod *modfile.File, pinned string) bool {
+	return pinned == "" || mod.Go == nil || version.Compare(goRelease(mod.Go.Version), pinned) <= 0
+}
+
+// outdatedModule looks up the newest overall and the newest Go-compatible
+// version of one requirement without running go get.
+func outdatedModule(proxy *GoProxy, r *modfile.Require, pinned string) OutdatedModule {
+	m := OutdatedModule{
+		ModulePath:       r.Mod.Path,
+		Version:          r.Mod.Version,
+		Latest:           r.Mod.Version,
+		LatestCompatible: r.Mod.Version,
+	}
+	versions, err := proxy.FetchVersions(r.Mod.Path, r.Mod.Version)
+	if err != nil {
+		m.Error = err.Error()
+		return m
+	}
+	for i, v := range versions {
+		mod, err := proxy.FetchModFile(r.Mod.Path, v.Version)
+		if err != nil {
+			m.Error = err.Error()
+			return m
+		}
+		if i == 0 {
+			m.Latest = v.Version
+			if mod.Go != nil {
+				m.LatestGo = mod.Go.Version
+			}
+		}
+		if goCompatible(mod, pinned) {
+			m.LatestCompatible = v.Version
+			break
+		}
+	}
+	return m
+}
+
+// outdatedReport builds the outdated report for the selected direct dependencies.
+func outdatedReport(original *modfile.File) (string, []OutdatedModule) {
+	proxy := NewGoProxy(config.ModuleProxy)
+	pinned := pinnedGo(original)
+	var report []OutdatedModule
+	for _, r := range selectedDependencies(original) {
+		if r.Indirect {
+			continue
+		}
+		report = append(report, outdatedModule(proxy, r, pinned))
+	}
+	return pinned, report
+}
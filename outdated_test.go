@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+func TestOutdatedModule(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/example.com/mod/@v/list":
+			fmt.Fprintln(w, "v1.0.0\nv1.1.0\nv1.2.0\nv1.3.0")
+		case "/example.com/mod/@v/v1.3.0.mod":
+			fmt.Fprintln(w, "module example.com/mod\n\ngo 1.24.0")
+		case "/example.com/mod/@v/v1.2.0.mod":
+			fmt.Fprintln(w, "module example.com/mod\n\ngo 1.22.1")
+		case "/example.com/mod/@v/v1.1.0.mod":
+			fmt.Fprintln(w, "module example.com/mod\n\ngo 1.22")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	proxy := NewGoProxy(server.URL)
+	r := &modfile.Require{Mod: module.Version{Path: "example.com/mod", Version: "v1.0.0"}}
+
+	got := outdatedModule(proxy, r, "go1.22.0")
+	want := OutdatedModule{
+		ModulePath:       "example.com/mod",
+		Version:          "v1.0.0",
+		Latest:           "v1.3.0",
+		LatestGo:         "1.24.0",
+		LatestCompatible: "v1.1.0",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("outdatedModule mismatch (-want +got):\n%s", diff)
+	}
+
+	got = outdatedModule(proxy, r, "go1.23.4")
+	if got.LatestCompatible != "v1.2.0" {
+		t.Errorf("LatestCompatible = %q, want v1.2.0", got.LatestCompatible)
+	}
+}
@@ -1,8 +1,11 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"strings"
+	"sync"
 )
 
 type Output interface {
@@ -17,6 +20,22 @@ type Output interface {
 	Write(buf []byte) (int, error)
 	Println(text ...string)
 	PrintSummary(results []Result)
+	PrintOutdated(pinnedGo string, report []OutdatedModule)
+	PrintMatrix(matrix CompatibilityMatrix)
+	PrintExplain(explanation ModuleExplanation)
+	// Buffered returns an Output of the same format writing to w, used to collect
+	// one module's block from a parallel worker before it is flushed with writeBlock.
+	Buffered(w io.Writer) Output
+}
+
+var outputMu sync.Mutex
+
+// writeBlock copies a block collected through Output.Buffered to out as one unit,
+// so parallel workers never interleave their logs.
+func writeBlock(buf *bytes.Buffer) {
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	out.Write(buf.Bytes())
 }
 
 func joinAny(text ...any) string {
@@ -37,3 +56,43 @@ func strOrDash(str string) string {
 	}
 	return str
 }
+
+// graphSummary describes the module graph change of a result, or "" when it
+// did not change.
+func graphSummary(r Result) string {
+	var parts []string
+	if len(r.ModulesAdded) > 0 {
+		parts = append(parts, fmt.Sprintf("+%d modules (%s)", len(r.ModulesAdded), strings.Join(r.ModulesAdded, ", ")))
+	}
+	if len(r.ModulesRemoved) > 0 {
+		parts = append(parts, fmt.Sprintf("-%d modules (%s)", len(r.ModulesRemoved), strings.Join(r.ModulesRemoved, ", ")))
+	}
+	if r.GoSumAdded > 0 || r.GoSumRemoved > 0 {
+		parts = append(parts, fmt.Sprintf("go.sum +%d -%d lines", r.GoSumAdded, r.GoSumRemoved))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// reasonSuffix formats an optional reason to append to a summary line.
+func reasonSuffix(reason string) string {
+	if reason == "" {
+		return ""
+	}
+	return ": " + reason
+}
+
+// latestWithGo formats the newest version together with the Go version it requires.
+func latestWithGo(m OutdatedModule) string {
+	if m.LatestGo == "" || m.Latest == m.Version {
+		return m.Latest
+	}
+	return m.Latest + " (go " + m.LatestGo + ")"
+}
+
+// blockersText joins the reasons a version cannot be adopted.
+func blockersText(v VersionBlockers) string {
+	if len(v.Reasons) == 0 {
+		return "no blocker in go.mod metadata (go get, build or -exec may still fail)"
+	}
+	return strings.Join(v.Reasons, "; ")
+}
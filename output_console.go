@@ -2,20 +2,42 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"strings"
 )
 
-type OutputConsole struct{}
+type OutputConsole struct {
+	// w receives both stdout and stderr text when set (see Buffered); nil writes to the process streams.
+	w io.Writer
+}
 
 var _ Output = (*OutputConsole)(nil)
 
+func (out *OutputConsole) stdout() io.Writer {
+	if out.w != nil {
+		return out.w
+	}
+	return os.Stdout
+}
+
+func (out *OutputConsole) stderr() io.Writer {
+	if out.w != nil {
+		return out.w
+	}
+	return os.Stderr
+}
+
+func (out *OutputConsole) Buffered(w io.Writer) Output {
+	return &OutputConsole{w: w}
+}
+
 func (out *OutputConsole) Begin(text ...any) {
 	if len(text) == 0 {
 		return
 	}
 
-	fmt.Println(joinAny(text...))
+	fmt.Fprintln(out.stdout(), joinAny(text...))
 }
 
 func (out *OutputConsole) End(text ...any) {
@@ -23,7 +45,7 @@ func (out *OutputConsole) End(text ...any) {
 		return
 	}
 
-	fmt.Println(joinAny(text...))
+	fmt.Fprintln(out.stdout(), joinAny(text...))
 }
 
 func (out *OutputConsole) Header(text string) {
@@ -31,7 +53,7 @@ func (out *OutputConsole) Header(text string) {
 		return
 	}
 
-	fmt.Println(color(text, ColorBold))
+	fmt.Fprintln(out.stdout(), color(text, ColorBold))
 }
 
 func (out *OutputConsole) BeginPreformatted(text ...any) {
@@ -39,7 +61,7 @@ func (out *OutputConsole) BeginPreformatted(text ...any) {
 		return
 	}
 
-	fmt.Println(joinAny(text...))
+	fmt.Fprintln(out.stdout(), joinAny(text...))
 }
 
 func (out *OutputConsole) EndPreformatted(text ...any) {
@@ -47,7 +69,7 @@ func (out *OutputConsole) EndPreformatted(text ...any) {
 		return
 	}
 
-	fmt.Println(joinAny(text...))
+	fmt.Fprintln(out.stdout(), joinAny(text...))
 }
 
 func (out *OutputConsole) EndPreformattedCond(render bool, text ...any) {
@@ -55,7 +77,7 @@ func (out *OutputConsole) EndPreformattedCond(render bool, text ...any) {
 }
 
 func (out *OutputConsole) Write(buf []byte) (int, error) {
-	return os.Stdout.Write(buf)
+	return out.stdout().Write(buf)
 }
 
 func (out *OutputConsole) Println(text ...string) {
@@ -63,15 +85,15 @@ func (out *OutputConsole) Println(text ...string) {
 		return
 	}
 
-	fmt.Println(strings.Join(text, " "))
+	fmt.Fprintln(out.stdout(), strings.Join(text, " "))
 }
 
 func (out *OutputConsole) Error(str ...string) {
-	fmt.Fprintln(os.Stderr, color(strings.Join(str, " "), ColorRed))
+	fmt.Fprintln(out.stderr(), color(strings.Join(str, " "), ColorRed))
 }
 
 func (out *OutputConsole) Fatal(msg string, code ...int) {
-	fmt.Fprintln(os.Stderr, msg)
+	fmt.Fprintln(out.stderr(), msg)
 
 	if len(code) == 0 {
 		os.Exit(1)
@@ -97,10 +119,123 @@ func (out *OutputConsole) PrintSummary(results []Result) {
 			}
 		}
 		if r.VersionAfter != "" && r.VersionAfter != r.VersionBefore && action != "skipped" {
-			out.Println(r.ModulePath, action, r.VersionBefore, "->", r.VersionAfter)
+			if r.UnblockedByGo != "" {
+				out.Println(r.ModulePath, action, r.VersionBefore, "->", r.VersionAfter, "(unblocked by go "+r.UnblockedByGo+")")
+			} else {
+				out.Println(r.ModulePath, action, r.VersionBefore, "->", r.VersionAfter)
+			}
+		} else if r.ExcludedBy != "" && (r.ExcludedBy != r.ModulePath || r.Reason != "") {
+			out.Println(r.ModulePath, action, "(by "+r.ExcludedBy+reasonSuffix(r.Reason)+")")
 		} else {
 			out.Println(r.ModulePath, action)
 
 		}
+		if len(r.Fixes) > 0 {
+			out.Println("  ", "fixes", advisoryList(r.Fixes, Advisory.String))
+		}
+		for _, a := range r.OpenAdvisories {
+			out.Println("  ", "still vulnerable:", a.openString())
+		}
+		for _, s := range r.BinarySizes {
+			out.Println("  ", "size:", s.String())
+		}
+		for _, c := range r.Benchmarks {
+			if r.BenchBlocked != "" {
+				out.Println("  ", "bench blocked "+r.BenchBlocked+":", c.String())
+			} else {
+				out.Println("  ", "bench:", c.String())
+			}
+		}
+		if g := graphSummary(r); g != "" {
+			out.Println("  ", "graph:", g)
+		}
+		for _, c := range r.APIChanges {
+			out.Println("  ", "incompatible:", c)
+		}
+		for _, issue := range r.LicenseIssues {
+			if r.LicenseBlocked != "" {
+				out.Println("  ", "license blocked "+r.LicenseBlocked+":", issue)
+			} else {
+				out.Println("  ", "license warning:", issue)
+			}
+		}
+		if len(r.CgoPackages) > 0 {
+			if r.CgoBlocked != "" {
+				out.Println("  ", "cgo blocked "+r.CgoBlocked+":", strings.Join(r.CgoPackages, ", "))
+			} else {
+				out.Println("  ", "cgo warning: requires cgo:", strings.Join(r.CgoPackages, ", "))
+			}
+		}
+		for _, f := range r.TestFailures {
+			out.Println("  ", "tests failed with", f.String())
+		}
+		for _, retry := range r.Retries {
+			out.Println("  ", "flaky with "+retry.Version+":", retry.String())
+		}
+		if r.HeldBack != "" {
+			out.Println("  ", "held back from", r.HeldBack, "by", r.HeldBy+reasonSuffix(r.Reason))
+		}
+		for _, c := range r.TransitiveChanges {
+			out.Println("  ", c.String())
+		}
+	}
+}
+
+func (out *OutputConsole) PrintOutdated(pinnedGo string, report []OutdatedModule) {
+	out.Println(color("outdated (compatible with "+strOrDash(pinnedGo)+"):", ColorBold))
+
+	for _, m := range report {
+		switch {
+		case m.Error != "":
+			out.Println(m.ModulePath, m.Version, "err:", m.Error)
+		case m.Latest == m.Version:
+			out.Println(m.ModulePath, m.Version, "up to date")
+		default:
+			out.Println(m.ModulePath, m.Version, "compatible", m.LatestCompatible, "latest", latestWithGo(m))
+		}
+	}
+}
+
+func (out *OutputConsole) PrintMatrix(matrix CompatibilityMatrix) {
+	out.Println(color("compatibility matrix (pinned "+strOrDash(matrix.PinnedGo)+"):", ColorBold))
+
+	for _, m := range matrix.Modules {
+		if m.Error != "" {
+			out.Println(m.ModulePath, m.Version, "err:", m.Error)
+			continue
+		}
+		cells := []string{m.ModulePath, m.Version}
+		for _, g := range matrix.GoVersions {
+			cells = append(cells, "go"+g+"="+m.Compatible[g])
+		}
+		out.Println(cells...)
+	}
+}
+
+func (out *OutputConsole) PrintExplain(ex ModuleExplanation) {
+	out.Println(color("explain "+ex.ModulePath+" "+ex.Version+" (pinned "+strOrDash(ex.PinnedGo)+"):", ColorBold))
+	if ex.Error != "" {
+		out.Error(ex.Error)
+		return
+	}
+	if len(ex.Versions) == 0 {
+		out.Println("no newer versions on module proxy")
+	}
+	for _, v := range ex.Versions {
+		out.Println(v.Version+":", blockersText(v))
+	}
+
+	out.Println(color("imported by:", ColorBold))
+	if len(ex.Importers) == 0 {
+		out.Println("  (no package of the main module imports it directly)")
+	}
+	for _, p := range ex.Importers {
+		out.Println("  " + p)
+	}
+	if len(ex.Why) > 0 {
+		out.Println(color("go mod why -m:", ColorBold))
+		for _, line := range ex.Why {
+			out.Println("  " + line)
+		}
 	}
 }
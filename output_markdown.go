@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"slices"
 	"strings"
 )
 
@@ -23,6 +24,10 @@ func NewOutputMarkdown(w io.Writer) *OutputMarkdown {
 	}
 }
 
+func (out *OutputMarkdown) Buffered(w io.Writer) Output {
+	return NewOutputMarkdown(w)
+}
+
 func (out *OutputMarkdown) Begin(text ...any) {
 	if len(text) == 0 {
 		fmt.Fprintf(out.w, "## Pinned Go version dependency update\n")
@@ -127,8 +132,14 @@ func markdownTableRow(cells ...string) string {
 
 func (out *OutputMarkdown) PrintSummary(results []Result) {
 	fmt.Fprintf(out.w, "\n## Summary\n\n")
-	fmt.Fprintln(out.w, "| Module | Status | Version |")
-	fmt.Fprintln(out.w, "| --- | --- | --- |")
+	sizes := slices.ContainsFunc(results, func(r Result) bool { return len(r.BinarySizes) > 0 })
+	if sizes {
+		fmt.Fprintln(out.w, "| Module | Status | Version | Size |")
+		fmt.Fprintln(out.w, "| --- | --- | --- | --- |")
+	} else {
+		fmt.Fprintln(out.w, "| Module | Status | Version |")
+		fmt.Fprintln(out.w, "| --- | --- | --- |")
+	}
 
 	for _, r := range results {
 		action := "E"
@@ -143,13 +154,338 @@ func (out *OutputMarkdown) PrintSummary(results []Result) {
 				action = "U"
 			}
 		}
-		fmt.Fprintln(out.w, markdownTableRow(
+		cells := []string{
 			r.ModulePath,
 			action,
-			strOrDash(r.VersionBefore)+" > "+strOrDash(r.VersionAfter),
-		))
+			strOrDash(r.VersionBefore) + " > " + strOrDash(r.VersionAfter),
+		}
+		if sizes {
+			cells = append(cells, sizeCell(r.BinarySizes))
+		}
+		fmt.Fprintln(out.w, markdownTableRow(cells...))
 	}
 
 	fmt.Fprintln(out.w, "")
 	fmt.Fprintln(out.w, "Status: **U** updated, **E** error, **X** excluded, **N** no newer versions on module proxy, **-** unchanged.")
+
+	out.printExcluded(results)
+	out.printHeldBack(results)
+	out.printSecurityFixes(results)
+	out.printOpenAdvisories(results)
+	out.printAPIChanges(results)
+	out.printLicenseIssues(results)
+	out.printCgo(results)
+	out.printTestFailures(results)
+	out.printRetries(results)
+	out.printBenchmarks(results)
+	out.printTransitiveChanges(results)
+	out.printGraphChanges(results)
+	out.printUnblockedByGo(results)
+}
+
+// sizeCell formats the binary size changes of a result for the summary table.
+func sizeCell(sizes []BinarySize) string {
+	if len(sizes) == 0 {
+		return "-"
+	}
+	if len(sizes) == 1 {
+		return sizes[0].Delta()
+	}
+	cells := make([]string, len(sizes))
+	for i, s := range sizes {
+		cells[i] = "`" + s.Package + "` " + s.Delta()
+	}
+	return strings.Join(cells, "<br>")
+}
+
+func (out *OutputMarkdown) printExcluded(results []Result) {
+	header := false
+	for _, r := range results {
+		if r.ExcludedBy == "" || r.ExcludedBy == r.ModulePath && r.Reason == "" {
+			continue
+		}
+		if !header {
+			fmt.Fprintf(out.w, "\n### Excluded\n\n")
+			header = true
+		}
+		fmt.Fprintf(out.w, "* `%s` by `%s`%s\n", r.ModulePath, r.ExcludedBy, reasonSuffix(r.Reason))
+	}
+}
+
+func (out *OutputMarkdown) printHeldBack(results []Result) {
+	header := false
+	for _, r := range results {
+		if r.HeldBack == "" {
+			continue
+		}
+		if !header {
+			fmt.Fprintf(out.w, "\n### Held back\n\n")
+			header = true
+		}
+		fmt.Fprintf(out.w, "* `%s` %s by `%s`%s\n", r.ModulePath, r.HeldBack, r.HeldBy, reasonSuffix(r.Reason))
+	}
+}
+
+func (out *OutputMarkdown) printSecurityFixes(results []Result) {
+	header := false
+	for _, r := range results {
+		if len(r.Fixes) == 0 {
+			continue
+		}
+		if !header {
+			fmt.Fprintf(out.w, "\n### Security fixes\n\n")
+			header = true
+		}
+		fmt.Fprintf(out.w, "* `%s` %s: %s\n", r.ModulePath, r.VersionAfter, advisoryList(r.Fixes, Advisory.String))
+	}
+}
+
+func (out *OutputMarkdown) printOpenAdvisories(results []Result) {
+	header := false
+	for _, r := range results {
+		if len(r.OpenAdvisories) == 0 {
+			continue
+		}
+		if !header {
+			fmt.Fprintf(out.w, "\n### Still vulnerable\n\n")
+			header = true
+		}
+		fmt.Fprintf(out.w, "* `%s` %s: %s\n", r.ModulePath, strOrDash(r.VersionAfter), advisoryList(r.OpenAdvisories, Advisory.openString))
+	}
+}
+
+func (out *OutputMarkdown) printAPIChanges(results []Result) {
+	header := false
+	for _, r := range results {
+		if len(r.APIChanges) == 0 {
+			continue
+		}
+		if !header {
+			fmt.Fprintf(out.w, "\n### Incompatible API changes\n\n")
+			header = true
+		}
+		fmt.Fprintf(out.w, "* `%s` %s > %s:\n", r.ModulePath, r.VersionBefore, r.VersionAfter)
+		for _, c := range r.APIChanges {
+			fmt.Fprintf(out.w, "  * %s\n", c)
+		}
+	}
+}
+
+func (out *OutputMarkdown) printLicenseIssues(results []Result) {
+	header := false
+	for _, r := range results {
+		if len(r.LicenseIssues) == 0 {
+			continue
+		}
+		if !header {
+			fmt.Fprintf(out.w, "\n### License issues\n\n")
+			header = true
+		}
+		if r.LicenseBlocked != "" {
+			fmt.Fprintf(out.w, "* `%s` %s **blocked**:\n", r.ModulePath, r.LicenseBlocked)
+		} else {
+			fmt.Fprintf(out.w, "* `%s` %s warning:\n", r.ModulePath, r.VersionAfter)
+		}
+		for _, issue := range r.LicenseIssues {
+			fmt.Fprintf(out.w, "  * %s\n", issue)
+		}
+	}
+}
+
+func (out *OutputMarkdown) printCgo(results []Result) {
+	header := false
+	for _, r := range results {
+		if len(r.CgoPackages) == 0 {
+			continue
+		}
+		if !header {
+			fmt.Fprintf(out.w, "\n### Requires cgo\n\n")
+			header = true
+		}
+		if r.CgoBlocked != "" {
+			fmt.Fprintf(out.w, "* `%s` %s **blocked**: %s\n", r.ModulePath, r.CgoBlocked, strings.Join(r.CgoPackages, ", "))
+		} else {
+			fmt.Fprintf(out.w, "* `%s` %s warning: %s\n", r.ModulePath, r.VersionAfter, strings.Join(r.CgoPackages, ", "))
+		}
+	}
+}
+
+func (out *OutputMarkdown) printTestFailures(results []Result) {
+	header := false
+	for _, r := range results {
+		if len(r.TestFailures) == 0 {
+			continue
+		}
+		if !header {
+			fmt.Fprintf(out.w, "\n### Test failures\n\n")
+			header = true
+		}
+		fmt.Fprintf(out.w, "* `%s`\n", r.ModulePath)
+		for _, f := range r.TestFailures {
+			tests := make([]string, len(f.Tests))
+			for i, t := range f.Tests {
+				tests[i] = "`" + t + "`"
+			}
+			fmt.Fprintf(out.w, "  * %s: %s\n", f.Version, strings.Join(tests, ", "))
+		}
+	}
+}
+
+func (out *OutputMarkdown) printRetries(results []Result) {
+	header := false
+	for _, r := range results {
+		if len(r.Retries) == 0 {
+			continue
+		}
+		if !header {
+			fmt.Fprintf(out.w, "\n### Flaky checks\n\n")
+			header = true
+		}
+		fmt.Fprintf(out.w, "* `%s`\n", r.ModulePath)
+		for _, retry := range r.Retries {
+			fmt.Fprintf(out.w, "  * %s: `%s` passed on run %d\n", retry.Version, retry.Check, retry.Runs)
+		}
+	}
+}
+
+func (out *OutputMarkdown) printBenchmarks(results []Result) {
+	header := false
+	for _, r := range results {
+		if len(r.Benchmarks) == 0 {
+			continue
+		}
+		if !header {
+			fmt.Fprintf(out.w, "\n### Benchmarks\n")
+			header = true
+		}
+		if r.BenchBlocked != "" {
+			fmt.Fprintf(out.w, "\n`%s` %s => %s **blocked**:\n\n", r.ModulePath, r.VersionBefore, r.BenchBlocked)
+		} else {
+			fmt.Fprintf(out.w, "\n`%s` %s => %s:\n\n", r.ModulePath, r.VersionBefore, r.VersionAfter)
+		}
+		fmt.Fprintln(out.w, "| Benchmark | Before | After | Delta | p |")
+		fmt.Fprintln(out.w, "| --- | --- | --- | --- | --- |")
+		for _, c := range r.Benchmarks {
+			delta := c.DeltaString()
+			if c.Regression {
+				delta = "**" + delta + "**"
+			}
+			fmt.Fprintln(out.w, markdownTableRow(c.Name, formatNs(c.Before), formatNs(c.After), delta, fmt.Sprintf("%.3f", c.P)))
+		}
+	}
+}
+
+func (out *OutputMarkdown) printUnblockedByGo(results []Result) {
+	header := false
+	for _, r := range results {
+		if r.UnblockedByGo == "" {
+			continue
+		}
+		if !header {
+			fmt.Fprintf(out.w, "\n### Unblocked by Go upgrade\n\n")
+			header = true
+		}
+		fmt.Fprintf(out.w, "* `%s` %s requires go %s\n", r.ModulePath, r.VersionAfter, r.UnblockedByGo)
+	}
+}
+
+func (out *OutputMarkdown) printTransitiveChanges(results []Result) {
+	header := false
+	for _, r := range results {
+		if len(r.TransitiveChanges) == 0 {
+			continue
+		}
+		if !header {
+			fmt.Fprintf(out.w, "\n### Transitive changes\n\n")
+			header = true
+		}
+		changes := make([]string, len(r.TransitiveChanges))
+		for i, c := range r.TransitiveChanges {
+			changes[i] = c.String()
+		}
+		fmt.Fprintf(out.w, "* `%s`: %s\n", r.ModulePath, strings.Join(changes, ", "))
+	}
+}
+
+func (out *OutputMarkdown) printGraphChanges(results []Result) {
+	header := false
+	for _, r := range results {
+		g := graphSummary(r)
+		if g == "" {
+			continue
+		}
+		if !header {
+			fmt.Fprintf(out.w, "\n### Module graph changes\n\n")
+			header = true
+		}
+		fmt.Fprintf(out.w, "* `%s`: %s\n", r.ModulePath, g)
+	}
+}
+
+func (out *OutputMarkdown) PrintOutdated(pinnedGo string, report []OutdatedModule) {
+	fmt.Fprintf(out.w, "\n## Outdated dependencies\n\n")
+	fmt.Fprintf(out.w, "Newest versions compatible with Go `%s`.\n\n", strOrDash(pinnedGo))
+	fmt.Fprintln(out.w, "| Module | Current | Compatible | Latest |")
+	fmt.Fprintln(out.w, "| --- | --- | --- | --- |")
+
+	for _, m := range report {
+		if m.Error != "" {
+			fmt.Fprintln(out.w, markdownTableRow(m.ModulePath, m.Version, "-", "error: "+m.Error))
+			continue
+		}
+		fmt.Fprintln(out.w, markdownTableRow(m.ModulePath, m.Version, m.LatestCompatible, latestWithGo(m)))
+	}
+}
+
+func (out *OutputMarkdown) PrintMatrix(matrix CompatibilityMatrix) {
+	fmt.Fprintf(out.w, "\n## Go compatibility matrix\n\n")
+	fmt.Fprintf(out.w, "Highest usable version per Go release; the first column is the pinned `%s`.\n\n", strOrDash(matrix.PinnedGo))
+
+	header := []string{"Module", "Current"}
+	separator := []string{"---", "---"}
+	for _, g := range matrix.GoVersions {
+		header = append(header, g)
+		separator = append(separator, "---")
+	}
+	fmt.Fprintln(out.w, markdownTableRow(header...))
+	fmt.Fprintln(out.w, markdownTableRow(separator...))
+
+	for _, m := range matrix.Modules {
+		cells := []string{m.ModulePath, m.Version}
+		for _, g := range matrix.GoVersions {
+			if m.Error != "" {
+				cells = append(cells, "error: "+m.Error)
+				continue
+			}
+			cells = append(cells, m.Compatible[g])
+		}
+		fmt.Fprintln(out.w, markdownTableRow(cells...))
+	}
+}
+
+func (out *OutputMarkdown) PrintExplain(ex ModuleExplanation) {
+	fmt.Fprintf(out.w, "\n## Explain `%s`\n\n", ex.ModulePath)
+	fmt.Fprintf(out.w, "Required version `%s`, pinned Go `%s`.\n", strOrDash(ex.Version), strOrDash(ex.PinnedGo))
+	if ex.Error != "" {
+		fmt.Fprintf(out.w, "\nError: %s\n", ex.Error)
+		return
+	}
+
+	fmt.Fprintln(out.w, "")
+	fmt.Fprintln(out.w, "| Version | Blockers |")
+	fmt.Fprintln(out.w, "| --- | --- |")
+	for _, v := range ex.Versions {
+		fmt.Fprintln(out.w, markdownTableRow(v.Version, blockersText(v)))
+	}
+
+	fmt.Fprintf(out.w, "\n### Imported by\n\n")
+	if len(ex.Importers) == 0 {
+		fmt.Fprintln(out.w, "No package of the main module imports it directly.")
+	}
+	for _, p := range ex.Importers {
+		fmt.Fprintf(out.w, "* `%s`\n", p)
+	}
+	if len(ex.Why) > 0 {
+		fmt.Fprintf(out.w, "\n```\n%s\n```\n", strings.Join(ex.Why, "\n"))
+	}
 }
@@ -77,3 +77,59 @@ Status: **U** updated, **E** error, **X** excluded, **N** no newer versions on m
 		t.Errorf("PrintSummary mismatch (-want +got):\n%s", diff)
 	}
 }
+
+func TestOutputMarkdownPrintSummaryTransitiveChanges(t *testing.T) {
+	var buf bytes.Buffer
+	out := NewOutputMarkdown(&buf)
+
+	out.printTransitiveChanges([]Result{
+		{
+			ModulePath:    "example.com/mod",
+			Success:       true,
+			VersionBefore: "v1.0.0",
+			VersionAfter:  "v2.0.0",
+			TransitiveChanges: []RequireChange{
+				{ModulePath: "example.com/dep", VersionBefore: "v0.1.0", VersionAfter: "v0.2.0"},
+				{ModulePath: "example.com/new", VersionAfter: "v1.0.0"},
+			},
+		},
+		{
+			ModulePath:    "example.com/unchanged",
+			Success:       true,
+			VersionBefore: "v1.0.0",
+			VersionAfter:  "v1.0.0",
+		},
+	})
+
+	expected := `
+### Transitive changes
+
+* ` + "`example.com/mod`" + `: example.com/dep v0.1.0 -> v0.2.0, example.com/new added v1.0.0
+`
+
+	if diff := cmp.Diff(expected, buf.String()); diff != "" {
+		t.Errorf("printTransitiveChanges mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestOutputMarkdownPrintSummaryReasons(t *testing.T) {
+	var buf bytes.Buffer
+	out := NewOutputMarkdown(&buf)
+
+	results := []Result{
+		{ModulePath: "example.com/held", Excluded: true, ExcludedBy: holdAnnotation, Reason: "waiting for upstream fix"},
+		{ModulePath: "example.com/plain", Excluded: true, ExcludedBy: "example.com/plain"},
+		{ModulePath: "example.com/capped", Success: true, VersionBefore: "v1.9.0", VersionAfter: "v1.9.3", HeldBack: "v1.10.0", HeldBy: "max v1.9", Reason: "v1.10 breaks our formatter"},
+	}
+	out.printExcluded(results)
+	out.printHeldBack(results)
+
+	expected := "\n### Excluded\n\n" +
+		"* `example.com/held` by `gobump:hold`: waiting for upstream fix\n" +
+		"\n### Held back\n\n" +
+		"* `example.com/capped` v1.10.0 by `max v1.9`: v1.10 breaks our formatter\n"
+
+	if diff := cmp.Diff(expected, buf.String()); diff != "" {
+		t.Errorf("summary sections mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -1,9 +1,15 @@
 package main
 
+import "io"
+
 type OutputNone struct{}
 
 var _ Output = (*OutputNone)(nil)
 
+func (out *OutputNone) Buffered(w io.Writer) Output {
+	return out
+}
+
 func (out *OutputNone) Begin(text ...any) {
 }
 
@@ -37,3 +43,12 @@ func (out *OutputNone) Fatal(msg string, code ...int) {
 
 func (out *OutputNone) PrintSummary(results []Result) {
 }
+
+func (out *OutputNone) PrintOutdated(pinnedGo string, report []OutdatedModule) {
+}
+
+func (out *OutputNone) PrintMatrix(matrix CompatibilityMatrix) {
+}
+
+func (out *OutputNone) PrintExplain(explanation ModuleExplanation) {
+}
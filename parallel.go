@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/semver"
+)
+
+// evaluateInWorkspace runs upgradeModule for r in a fresh isolated workspace
+// starting from startMod. Its log is collected and flushed as one block.
+func evaluateInWorkspace(proxy *GoProxy, r *modfile.Require, startMod *modfile.File, useGit bool) candidateEvaluation {
+	var buf bytes.Buffer
+	o := out.Buffered(&buf)
+	defer writeBlock(&buf)
+
+	ws, err := newIsolatedWorkspace(startMod, o, useGit)
+	if err != nil {
+		o.Error("failed to prepare workspace for", r.Mod.Path+":", err.Error())
+		return candidateEvaluation{newMod: startMod}
+	}
+	defer func() {
+		if err := ws.Remove(); err != nil {
+			o.Error("failed to remove workspace:", err.Error())
+		}
+	}()
+
+	return upgradeModule(ws, proxy, r, startMod)
+}
+
+// processParallel evaluates the candidates of all requires concurrently in
+// isolated workspaces (-parallel), then applies the winning versions one by
+// one to the main tree and runs the -exec commands once more as confirmation.
+func processParallel(ws *workspace, proxy *GoProxy, requires []*modfile.Require, okMod *modfile.File, commits *bumpCommitter) []Result {
+	evaluations := make([]candidateEvaluation, len(requires))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range config.Parallel {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				evaluations[i] = evaluateInWorkspace(proxy, requires[i], okMod, commits != nil)
+			}
+		}()
+	}
+	for i := range requires {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	before, saveErr := saveTreeState(ws, okMod, commits != nil)
+	if saveErr != nil {
+		out.Error("failed to save the tree state:", saveErr.Error())
+	}
+
+	var results []Result
+	applied := false
+	for i, r := range requires {
+		e := evaluations[i]
+		winner := requiredVersion(e.newMod, r.Mod.Path, r.Mod.Version)
+		e.newMod = okMod
+		if e.success {
+			current := requiredVersion(okMod, r.Mod.Path, r.Mod.Version)
+			if semver.Compare(winner, current) > 0 {
+				e.newMod, e.success = applyWinner(ws, r.Mod.Path, winner, okMod)
+				applied = applied || e.success
+			}
+		}
+		var result Result
+		okMod, result = finishModule(commits, i, r, okMod, e)
+		results = append(results, result)
+	}
+
+	if applied && !confirmUpgrades(ws) {
+		out.Error("confirmation run of -exec commands failed with all upgrades applied; restoring the tree and marking updated modules as failed")
+		if saveErr == nil {
+			if err := before.restore(ws); err != nil {
+				out.Error("failed to restore the tree:", err.Error())
+			}
+		}
+		for i := range results {
+			if results[i].VersionAfter != results[i].VersionBefore {
+				revertResult(&results[i])
+			}
+		}
+	}
+	return results
+}
+
+// treeState is the main tree before the winners of a parallel run are applied.
+type treeState struct {
+	head string        // commit with per-dependency commits, else empty
+	mod  *modfile.File // go.mod without git
+	sum  []byte        // go.sum without git, nil when there was none
+}
+
+// saveTreeState records the main tree, okMod being its go.mod.
+func saveTreeState(ws *workspace, okMod *modfile.File, useGit bool) (treeState, error) {
+	if useGit {
+		head, err := cmdOutput("git", "rev-parse", "HEAD")
+		if err != nil {
+			return treeState{}, fmt.Errorf("git rev-parse HEAD: %w", err)
+		}
+		return treeState{head: strings.TrimSpace(string(head))}, nil
+	}
+	sum, err := os.ReadFile(goSumPath(ws))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return treeState{}, err
+	}
+	return treeState{mod: okMod, sum: sum}, nil
+}
+
+// restore resets the main tree to the saved state, dropping the commits made
+// since with git.
+func (s treeState) restore(ws *workspace) error {
+	if s.head != "" {
+		return gitResetHard(s.head)
+	}
+	if err := saveMod(ws.GoModDst, s.mod); err != nil {
+		return err
+	}
+	if s.sum == nil {
+		if err := os.Remove(goSumPath(ws)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	return os.WriteFile(goSumPath(ws), s.sum, 0o644)
+}
+
+// revertResult marks the update of a result as failed after its bump was
+// undone, dropping what it reported about the new version.
+func revertResult(r *Result) {
+	r.Success = false
+	r.VersionAfter = r.VersionBefore
+	r.TransitiveChanges = nil
+	r.Fixes = nil
+	r.APIChanges = nil
+	r.ModulesAdded, r.ModulesRemoved = nil, nil
+	r.GoSumAdded, r.GoSumRemoved = 0, 0
+	r.BinarySizes = nil
+	if r.LicenseBlocked == "" {
+		r.LicenseIssues = nil
+	}
+	if r.CgoBlocked == "" {
+		r.CgoPackages = nil
+	}
+	if r.BenchBlocked == "" {
+		r.Benchmarks = nil
+	}
+}
+
+// confirmUpgrades runs the -exec commands and the -test-package tests with
+// all winners applied.
+func confirmUpgrades(ws *workspace) bool {
+	if _, ok := runCommands(ws, nil); !ok {
+		return false
+	}
+	if len(config.TestPackages) > 0 {
+		if _, _, ok := checkTests(ws, nil); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// applyWinner runs go get for a version that passed evaluation in an isolated
+// workspace, validating the result against the current main tree.
+func applyWinner(ws *workspace, modulePath, version string, okMod *modfile.File) (*modfile.File, bool) {
+	ws.Out.BeginPreformatted(config.GoBinary, "get", modulePath+"@"+version)
+	defer ws.Out.EndPreformatted()
+
+	newMod, err := attemptUpgrade(ws, modulePath, version)
+	if err == nil {
+		err = validateUpgrade(okMod, newMod, modulePath)
+	}
+	if err != nil {
+		ws.Out.Error(fmt.Sprintf("%s; reverting go.mod", err.Error()))
+		if err := saveMod(ws.GoModDst, okMod); err != nil {
+			ws.Out.Error("failed to revert go.mod:", err.Error())
+		}
+		return okMod, false
+	}
+	return newMod, true
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testModuleProxy serves example.com/dep v1.0.0 and v1.1.0 as a module proxy.
+func testModuleProxy(t *testing.T) *httptest.Server {
+	t.Helper()
+	files := map[string][]byte{"/example.com/dep/@v/list": []byte("v1.0.0\nv1.1.0\n")}
+	for _, v := range []string{"v1.0.0", "v1.1.0"} {
+		prefix := "/example.com/dep/@v/" + v
+		files[prefix+".info"] = []byte(`{"Version":"` + v + `","Time":"2024-01-01T00:00:00Z"}`)
+		files[prefix+".mod"] = []byte("module example.com/dep\n\ngo 1.21\n")
+		files[prefix+".zip"] = testModuleZip(t, "example.com/dep@"+v, map[string]string{
+			"go.mod": "module example.com/dep\n\ngo 1.21\n",
+			"dep.go": "package dep\n\nconst Version = \"" + v + "\"\n",
+		})
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		buf, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(buf)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestProcessParallelConfirmationFailure(t *testing.T) {
+	server := testModuleProxy(t)
+	t.Setenv("GOPROXY", server.URL)
+	t.Setenv("GOSUMDB", "off")
+	t.Setenv("GOFLAGS", "")
+
+	root := t.TempDir()
+	dir := filepath.Join(root, "main")
+	// check.sh passes in the isolated workspaces but fails in the main tree,
+	// whose parent holds fail-confirm.
+	writeFiles(t, dir, map[string]string{
+		"go.mod":   "module example.com/app\n\ngo 1.21\n\nrequire example.com/dep v1.0.0\n",
+		"main.go":  "package main\n\nimport \"example.com/dep\"\n\nfunc main() { println(dep.Version) }\n",
+		"check.sh": "test ! -f ../fail-confirm\n",
+	})
+	writeFiles(t, root, map[string]string{"fail-confirm": ""})
+	t.Chdir(dir)
+	tidy := exec.Command("go", "mod", "tidy")
+	if buf, err := tidy.CombinedOutput(); err != nil {
+		t.Fatalf("go mod tidy: %v\n%s", err, buf)
+	}
+	modBefore, _ := os.ReadFile("go.mod")
+	sumBefore, _ := os.ReadFile("go.sum")
+
+	out = &OutputNone{}
+	config = &AppConfig{
+		GoBinary:     "go",
+		GoModSrc:     "go.mod",
+		GoModDst:     "go.mod",
+		NoGit:        true,
+		Parallel:     2,
+		Retries:      5,
+		Update:       updateMajor,
+		LicenseCheck: checkOff,
+		CgoCheck:     checkOff,
+		Commands:     stringSlice{"sh check.sh"},
+	}
+	okMod, err := parseMod("go.mod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := processParallel(mainWorkspace(), NewGoProxy(server.URL), okMod.Require, okMod, nil)
+
+	if len(results) != 1 || results[0].Success || results[0].VersionAfter != "v1.0.0" {
+		t.Errorf("results = %+v, want a failed update staying at v1.0.0", results)
+	}
+	modAfter, _ := os.ReadFile("go.mod")
+	sumAfter, _ := os.ReadFile("go.sum")
+	if string(modAfter) != string(modBefore) {
+		t.Errorf("go.mod not restored:\n%s", modAfter)
+	}
+	if string(sumAfter) != string(sumBefore) || strings.Contains(string(sumAfter), "v1.1.0") {
+		t.Errorf("go.sum not restored:\n%s", sumAfter)
+	}
+}
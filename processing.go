@@ -6,106 +6,410 @@ import (
 	"strings"
 
 	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
 )
 
 // attemptUpgrade tries to upgrade a module to a specific version.
-func attemptUpgrade(modulePath, version string) (*modfile.File, error) {
-	err := cmd(config.GoBinary, "get", modulePath+"@"+version)
+func attemptUpgrade(ws *workspace, modulePath, version string) (*modfile.File, error) {
+	err := ws.cmd(config.GoBinary, "get", modulePath+"@"+version)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get module: %w", err)
 	}
-	return parseMod(config.GoModSrc)
+	return parseMod(ws.GoModSrc)
 }
 
-// validateUpgrade checks if the upgrade is valid.
-func validateUpgrade(originalMod, newMod *modfile.File) error {
+// validateUpgrade checks if the upgrade of modulePath is valid.
+func validateUpgrade(originalMod, newMod *modfile.File, modulePath string) error {
 	if newMod == nil || newMod.Go == nil {
 		return fmt.Errorf("parsing error")
 	}
-	if strings.TrimSuffix(originalMod.Go.Version, ".0") != strings.TrimSuffix(newMod.Go.Version, ".0") {
-		return fmt.Errorf("upgrade changes required Go version %s => %s", originalMod.Go.Version, newMod.Go.Version)
+	if err := validateDirectives(originalMod, newMod); err != nil {
+		return err
 	}
-	return nil
+	return validateRequireChanges(originalMod, newMod, modulePath)
+}
+
+// candidateEvaluation is the outcome of upgrading one module.
+type candidateEvaluation struct {
+	newMod          *modfile.File // last good go.mod after the module was processed
+	success         bool
+	noProxyVersions bool   // the proxy listed no newer versions (no go get run)
+	heldBy          string // constraint that ruled out heldLatest, see filterCandidates
+	heldLatest      string // newest version on the proxy when it was held back
+	// licenseBlocked is the first version rejected by -license-check block,
+	// licenseIssues its issues.
+	licenseBlocked string
+	licenseIssues  []string
+	graph          *graphDelta // module graph change of the accepted version, nil when unknown
+	// cgoPackages are the imported packages needing cgo since the accepted
+	// version, or since cgoBlocked, the first version -cgo-check block rejected.
+	cgoPackages []string
+	cgoBlocked  string
+	binarySizes []BinarySize // -size-package sizes with the accepted version
+	// benchmarks compares the -bench-package benchmarks of the accepted
+	// version, or of benchBlocked, the first version -max-bench-regression
+	// rejected; benchSamples are the accepted version's samples.
+	benchmarks   []BenchComparison
+	benchBlocked string
+	benchSamples benchSamples
+	testFailures []TestFailure // -test-package failures of rejected versions
+	retries      []Retry       // checks that passed only when re-run, of any version
+}
+
+// newestVersion returns the highest of versions, which are sorted newest
+// first by the proxy but ascending by securityCandidates.
+func newestVersion(versions []module.Version) string {
+	return slices.MaxFunc(versions, func(a, b module.Version) int {
+		return semver.Compare(a.Version, b.Version)
+	}).Version
 }
 
 // upgradeModule attempts to upgrade a single module.
-// The bool is success; the third return is true when the proxy listed no newer versions (no go get run).
-func upgradeModule(proxy *GoProxy, r *modfile.Require, okMod *modfile.File) (*modfile.File, bool, bool) {
-	var success bool
-	var noProxyVersions bool
-	out.BeginPreformatted(config.GoBinary, "get", r.Mod.Path)
-	defer out.EndPreformattedCond(!success)
+func upgradeModule(ws *workspace, proxy *GoProxy, r *modfile.Require, okMod *modfile.File) candidateEvaluation {
+	e := candidateEvaluation{newMod: okMod}
+	ws.Out.BeginPreformatted(config.GoBinary, "get", r.Mod.Path)
+	defer ws.Out.EndPreformattedCond(!e.success)
 
 	versions, err := proxy.FetchVersions(r.Mod.Path, r.Mod.Version)
 	if err != nil {
-		out.Error("failed to fetch versions:", err.Error())
-		return okMod, success, false
+		ws.Out.Error("failed to fetch versions:", err.Error())
+		return e
+	}
+	if len(versions) == 0 {
+		e.success = true
+		e.noProxyVersions = true
+		return e
+	}
+	if config.Security {
+		versions = securityCandidates(r.Mod.Path, versions)
+		if len(versions) == 0 {
+			ws.Out.Error("no newer version without known vulnerabilities")
+			return e
+		}
+	}
+	latest := newestVersion(versions)
+	versions, e.heldBy = filterCandidates(r.Mod.Path, r.Mod.Version, versions)
+	if e.heldBy != "" {
+		e.heldLatest = latest
+		ws.Out.Println("held back from", latest, "by", e.heldBy)
 	}
 	if len(versions) == 0 {
-		success = true
-		noProxyVersions = true
-		return okMod, success, noProxyVersions
+		e.success = true
+		return e
+	}
+
+	before, err := readGraphState(ws)
+	if err != nil {
+		ws.Out.Error("failed to read module graph:", err.Error())
+	}
+	graphKnown := err == nil
+	var cgoBefore map[string]bool
+	if config.CgoCheck != checkOff {
+		if cgoBefore, err = cgoPackages(ws); err != nil {
+			ws.Out.Error("failed to list cgo packages:", err.Error())
+		}
 	}
 
 	for vi, version := range versions {
 		if vi >= config.Retries {
-			out.Error("too many failed attempts, giving up")
+			ws.Out.Error("too many failed attempts, giving up")
 			break
 		}
 
-		newMod, err := attemptUpgrade(r.Mod.Path, version.Version)
+		newMod, err := attemptUpgrade(ws, r.Mod.Path, version.Version)
 		if err != nil {
-			out.Error("upgrade unsuccessful, reverting go.mod")
-			if err := saveMod(config.GoModDst, okMod); err != nil {
-				out.Error("failed to revert go.mod:", err.Error())
+			ws.Out.Error("upgrade unsuccessful, reverting go.mod")
+			if err := saveMod(ws.GoModDst, okMod); err != nil {
+				ws.Out.Error("failed to revert go.mod:", err.Error())
 			}
 			continue
 		}
 
-		if err := validateUpgrade(okMod, newMod); err != nil {
-			out.Error(fmt.Sprintf("%s; reverting go.mod", err.Error()))
-			if err := saveMod(config.GoModDst, okMod); err != nil {
-				out.Error("failed to revert go.mod:", err.Error())
+		if err := validateUpgrade(okMod, newMod, r.Mod.Path); err != nil {
+			ws.Out.Error(fmt.Sprintf("%s; reverting go.mod", err.Error()))
+			if err := saveMod(ws.GoModDst, okMod); err != nil {
+				ws.Out.Error("failed to revert go.mod:", err.Error())
 			}
 			continue
 		}
 
 		if config.Verbose {
-			out.Println("compare", okMod.Go.Version, " => ", newMod.Go.Version)
+			ws.Out.Println("compare", okMod.Go.Version, " => ", newMod.Go.Version)
+		}
+
+		if !checkAPIChanges(ws, proxy, r, version.Version, okMod) {
+			continue
+		}
+
+		var graph *graphDelta
+		if graphKnown {
+			if after, err := readGraphState(ws); err != nil {
+				ws.Out.Error("failed to read module graph:", err.Error())
+			} else {
+				d := diffGraphStates(before, after)
+				graph = &d
+			}
+		}
+
+		if graph != nil {
+			if v := graphViolation(*graph); v != "" {
+				ws.Out.Error(v + "; reverting go.mod")
+				if err := saveMod(ws.GoModDst, okMod); err != nil {
+					ws.Out.Error("failed to revert go.mod:", err.Error())
+				}
+				continue
+			}
+		}
+
+		if issues, ok := checkLicenses(ws, proxy, r, version.Version, okMod, addedModules(r.Mod.Path, okMod, newMod, graph)); !ok {
+			if e.licenseBlocked == "" {
+				e.licenseBlocked, e.licenseIssues = version.Version, issues
+			}
+			continue
+		}
+
+		var cgo []string
+		if cgoBefore != nil {
+			var ok bool
+			if cgo, ok = checkCgo(ws, cgoBefore, okMod); !ok {
+				if e.cgoBlocked == "" {
+					e.cgoBlocked, e.cgoPackages = version.Version, cgo
+				}
+				continue
+			}
+		}
+
+		var sizes []BinarySize
+		if binarySizes != nil {
+			var ok bool
+			if sizes, ok = checkBinarySizes(ws, okMod); !ok {
+				continue
+			}
 		}
 
-		if !runCommands(okMod) {
+		retries, ok := runCommands(ws, okMod)
+		e.addRetries(version.Version, retries)
+		if !ok {
 			continue
 		}
 
-		success = true
-		return newMod, success, false
+		if len(config.TestPackages) > 0 {
+			failed, retries, ok := checkTests(ws, okMod)
+			e.addRetries(version.Version, retries)
+			if !ok {
+				if len(failed) > 0 {
+					e.testFailures = append(e.testFailures, TestFailure{Version: version.Version, Tests: failed})
+				}
+				continue
+			}
+		}
+
+		var benchmarks []BenchComparison
+		var samples benchSamples
+		if benchBaseline != nil {
+			var ok bool
+			if benchmarks, samples, ok = checkBenchmarks(ws, okMod); !ok {
+				if e.benchBlocked == "" && benchmarks != nil {
+					e.benchBlocked, e.benchmarks = version.Version, benchmarks
+				}
+				continue
+			}
+		}
+
+		e.success = true
+		e.newMod = newMod
+		e.graph = graph
+		e.binarySizes = sizes
+		e.benchSamples = samples
+		if e.benchBlocked == "" {
+			e.benchmarks = benchmarks
+		}
+		if e.cgoBlocked == "" {
+			e.cgoPackages = cgo
+		}
+		return e
 	}
-	return okMod, success, false
+	return e
+}
+
+// checkAPIChanges computes the API changes of upgrading r to version with
+// -apidiff and reports whether the version is acceptable; versions with
+// incompatible changes in imported packages are rejected with -reject-incompatible.
+func checkAPIChanges(ws *workspace, proxy *GoProxy, r *modfile.Require, version string, revertTo *modfile.File) bool {
+	if !config.APIDiff && !config.RejectIncompatible {
+		return true
+	}
+	changes, err := moduleAPIChanges(proxy, r.Mod.Path, r.Mod.Version, version)
+	if err != nil {
+		ws.Out.Error("failed to compare API:", err.Error())
+		return true
+	}
+	if !config.RejectIncompatible {
+		return true
+	}
+	imported, err := importedAPIChanges(changes)
+	if err != nil {
+		ws.Out.Error("failed to list imported packages:", err.Error())
+		return true
+	}
+	if len(imported) == 0 {
+		return true
+	}
+	for _, c := range imported {
+		ws.Out.Println("incompatible API change:", c.String())
+	}
+	ws.Out.Error("incompatible API changes in imported packages, reverting go.mod")
+	if err := saveMod(ws.GoModDst, revertTo); err != nil {
+		ws.Out.Error("failed to revert go.mod:", err.Error())
+	}
+	return false
 }
 
 // runCommands executes post-upgrade commands against the current go.mod on disk
-// (expected to match a successful upgrade). On failure it restores revertTo.
-func runCommands(revertTo *modfile.File) bool {
+// (expected to match a successful upgrade). Failing commands are re-run up to
+// -exec-retries times; it returns the ones that passed on a re-run. A command
+// failing with the same exit status and output as in baselineFailures is
+// ignored. On failure it restores revertTo, unless nil.
+func runCommands(ws *workspace, revertTo *modfile.File) ([]Retry, bool) {
+	var retries []Retry
 	for _, c := range config.Commands {
 		if c == "" {
 			continue
 		}
-		out.BeginPreformatted(c)
-		if err := cmds(c); err != nil {
-			out.Error("tests failed, reverting go.mod")
-			if err := saveMod(config.GoModDst, revertTo); err != nil {
-				out.Error("failed to revert go.mod:", err.Error())
+		ws.Out.BeginPreformatted(c)
+		var known *commandFailure
+		if f, ok := baselineFailures[c]; ok {
+			known = &f
+		}
+		runs, failure := runWithRetries(ws, c, config.ExecRetries, known)
+		if failure != nil {
+			if known != nil && *failure == *known {
+				ws.Out.Println("command failed the same way before any bump, ignoring")
+				ws.Out.EndPreformattedCond(true)
+				continue
 			}
-			out.EndPreformattedCond(false)
-			return false
+			if known != nil {
+				ws.Out.Error("command fails differently than before any bump")
+			}
+			if revertTo == nil {
+				ws.Out.Error("command failed")
+			} else {
+				ws.Out.Error("tests failed, reverting go.mod")
+				if err := saveMod(ws.GoModDst, revertTo); err != nil {
+					ws.Out.Error("failed to revert go.mod:", err.Error())
+				}
+			}
+			ws.Out.EndPreformattedCond(false)
+			return retries, false
+		}
+		if runs > 1 {
+			retries = append(retries, Retry{Check: c, Runs: runs})
 		}
-		out.EndPreformattedCond(true)
+		ws.Out.EndPreformattedCond(true)
 	}
-	return true
+	return retries, true
 }
 
-func process(original *modfile.File) []Result {
+// requiredVersion returns the version of modulePath required by mod, or fallback when absent.
+func requiredVersion(mod *modfile.File, modulePath, fallback string) string {
+	if mod == nil {
+		return fallback
+	}
+	mi := slices.IndexFunc(mod.Require, func(re *modfile.Require) bool {
+		return re.Mod.Path == modulePath
+	})
+	if mi == -1 {
+		return fallback
+	}
+	return mod.Require[mi].Mod.Version
+}
+
+// finishModule commits or resets the main tree after requires[i] was processed
+// (commits is nil without git) and returns the new last good go.mod together
+// with the module's Result.
+func finishModule(commits *bumpCommitter, i int, r *modfile.Require, okMod *modfile.File, e candidateEvaluation) (*modfile.File, Result) {
+	newMod, upgradeSuccess := e.newMod, e.success
+	versionAfter := requiredVersion(newMod, r.Mod.Path, r.Mod.Version)
+
+	if commits != nil {
+		commits.finish(i, upgradeSuccess, versionAfter)
+	}
+
+	result := Result{
+		ModulePath:      r.Mod.Path,
+		VersionBefore:   r.Mod.Version,
+		VersionAfter:    versionAfter,
+		NoProxyVersions: e.noProxyVersions,
+	}
+	result.Fixes = advisoriesFixed(r.Mod.Path, r.Mod.Version, versionAfter)
+	result.APIChanges = apiChangeList(r.Mod.Path, r.Mod.Version, versionAfter)
+	if e.licenseBlocked != "" {
+		result.LicenseBlocked = e.licenseBlocked
+		result.LicenseIssues = e.licenseIssues
+	} else if versionAfter != r.Mod.Version {
+		result.LicenseIssues = cachedLicenseIssues(r.Mod.Path, r.Mod.Version, versionAfter)
+	}
+	result.TestFailures = e.testFailures
+	result.Retries = e.retries
+	if e.heldBy != "" && semver.Compare(e.heldLatest, versionAfter) > 0 {
+		result.HeldBack = e.heldLatest
+		result.HeldBy = e.heldBy
+		result.Reason = config.Modules[r.Mod.Path].Reason
+	}
+
+	if e.cgoBlocked != "" || versionAfter != r.Mod.Version {
+		result.CgoBlocked = e.cgoBlocked
+		result.CgoPackages = e.cgoPackages
+	}
+	if upgradeSuccess && e.graph != nil && versionAfter != r.Mod.Version {
+		result.ModulesAdded = e.graph.added
+		result.ModulesRemoved = e.graph.removed
+		result.GoSumAdded = e.graph.sumAdded
+		result.GoSumRemoved = e.graph.sumRemoved
+	}
+
+	if upgradeSuccess && versionAfter != r.Mod.Version {
+		result.BinarySizes = e.binarySizes
+		for _, s := range e.binarySizes {
+			binarySizes[s.Package] = s.After
+		}
+		if e.benchSamples != nil {
+			benchBaseline = e.benchSamples
+		}
+	}
+	if e.benchBlocked != "" || (upgradeSuccess && versionAfter != r.Mod.Version) {
+		result.Benchmarks = e.benchmarks
+		result.BenchBlocked = e.benchBlocked
+	}
+
+	if upgradeSuccess {
+		result.TransitiveChanges = diffRequires(okMod, newMod, r.Mod.Path)
+		okMod = newMod
+		result.Success = true
+	} else {
+		result.Success = false
+	}
+	return okMod, result
+}
+
+// selectedDependencies returns the requirements selected by the positional arguments, or all of them.
+func selectedDependencies(original *modfile.File) []*modfile.Require {
+	dependencies := original.Require
+	if len(config.IncludeSelectors) > 0 {
+		dependencies = []*modfile.Require{}
+		for _, r := range original.Require {
+			if ok, _ := config.IncludeSelectors.match(r.Mod); ok {
+				dependencies = append(dependencies, r)
+			}
+		}
+	}
+	return dependencies
+}
+
+// process bumps the dependencies and returns their results. It returns an
+// error, before changing go.mod, when the baseline checks fail.
+func process(original *modfile.File) ([]Result, error) {
 	var results []Result
 	proxy := NewGoProxy(config.ModuleProxy)
 	okMod, err := parseMod(config.GoModSrc)
@@ -114,83 +418,98 @@ func process(original *modfile.File) []Result {
 	}
 
 	perDepGit := perDependencyGitEnabled()
+	ws := mainWorkspace()
 
-	dependencies := original.Require
-	if len(config.Dependencies) > 0 {
-		dependencies = []*modfile.Require{}
-		for _, r := range original.Require {
-			for _, d := range config.Dependencies {
-				if r.Mod.Path == d {
-					dependencies = append(dependencies, r)
-				}
-			}
+	originalGo := ""
+	if okMod.Go != nil {
+		originalGo = okMod.Go.Version
+	}
+	if err := establishBaseline(ws); err != nil {
+		return nil, err
+	}
+
+	if config.TargetGo != "" {
+		okMod, err = raiseGoDirective(ws, okMod, perDepGit)
+		if err != nil {
+			out.Fatal(err.Error(), ERR_WRITE)
 		}
 	}
 
+	for _, w := range obsoleteConstraints(okMod) {
+		out.Error("warning: obsolete constraint:", w)
+	}
+
+	dependencies := selectedDependencies(original)
+
+	if config.GraphOrder {
+		dependencies = graphOrderedDependencies(dependencies)
+	}
+
+	var pending []*modfile.Require
 	for _, r := range dependencies {
-		if r.Indirect {
+		if config.Security {
+			// Indirect requirements are bumped too, unaffected ones are left alone.
+			if len(securityAdvisories(r.Mod.Path, r.Mod.Version)) == 0 {
+				continue
+			}
+		} else if r.Indirect {
 			continue
 		}
 
-		excluded := false
-		if slices.Contains(config.Exclude, r.Mod.Path) {
+		if sel := excludedBy(r.Mod); sel != "" {
 			results = append(results, Result{
 				ModulePath:    r.Mod.Path,
 				VersionBefore: r.Mod.Version,
 				VersionAfter:  r.Mod.Version,
 				Success:       false,
 				Excluded:      true,
+				ExcludedBy:    sel,
+				Reason:        config.Modules[r.Mod.Path].Reason,
 			})
-			excluded = true
-		}
-		if excluded {
 			continue
 		}
+		pending = append(pending, r)
+	}
 
-		newMod, upgradeSuccess, noProxyVersions := upgradeModule(proxy, r, okMod)
-
-		versionAfter := r.Mod.Version
-		if newMod != nil {
-			mi := slices.IndexFunc(newMod.Require, func(re *modfile.Require) bool {
-				return re.Mod.Path == r.Mod.Path
-			})
-			if mi != -1 {
-				versionAfter = newMod.Require[mi].Mod.Version
-			}
-		}
-
-		if perDepGit {
-			if !upgradeSuccess {
-				if err := gitResetHardHEAD(); err != nil {
-					out.Error("git reset/clean failed:", err.Error())
-				}
-			} else if versionAfter != r.Mod.Version && gitWorktreeDiffersFromHEAD() {
-				if err := gitCommitDependencyBump(r.Mod.Path, r.Mod.Version, versionAfter); err != nil {
-					out.Error("git commit failed:", err.Error())
-				}
+	if config.Security && vulnDB.reachable != nil {
+		// Modules with reachable vulnerabilities go first.
+		slices.SortStableFunc(pending, func(a, b *modfile.Require) int {
+			ra, rb := hasReachableAdvisory(a.Mod.Path, a.Mod.Version), hasReachableAdvisory(b.Mod.Path, b.Mod.Version)
+			switch {
+			case ra && !rb:
+				return -1
+			case rb && !ra:
+				return 1
 			}
-		}
-
-		result := Result{
-			ModulePath:      r.Mod.Path,
-			VersionBefore:   r.Mod.Version,
-			VersionAfter:    versionAfter,
-			NoProxyVersions: noProxyVersions,
-		}
+			return 0
+		})
+	}
+	pending = groupRequires(pending)
+	var commits *bumpCommitter
+	if perDepGit {
+		commits = &bumpCommitter{requires: pending}
+	}
 
-		if upgradeSuccess {
-			okMod = newMod
-			result.Success = true
-		} else {
-			result.Success = false
+	if config.Parallel > 1 {
+		results = append(results, processParallel(ws, proxy, pending, okMod, commits)...)
+	} else {
+		for i, r := range pending {
+			var result Result
+			okMod, result = finishModule(commits, i, r, okMod, upgradeModule(ws, proxy, r, okMod))
+			results = append(results, result)
 		}
+	}
 
-		results = append(results, result)
+	if config.TargetGo != "" {
+		markGoUnblocked(proxy, results, originalGo)
+	}
+	if vulnDB != nil {
+		markOpenAdvisories(proxy, results, pinnedGo(okMod))
 	}
 
 	slices.SortFunc(results, func(a, b Result) int {
 		return strings.Compare(a.ModulePath, b.ModulePath)
 	})
 
-	return results
+	return results, nil
 }
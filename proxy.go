@@ -11,6 +11,7 @@ import (
 	"slices"
 	"strings"
 
+	"golang.org/x/mod/modfile"
 	"golang.org/x/mod/module"
 	"golang.org/x/mod/semver"
 )
@@ -158,6 +159,78 @@ func (p *GoProxy) FetchVersionInfo(modPath, version string) (moduleVersionInfo,
 	return info, nil
 }
 
+// FetchModFile returns the go.mod file the module proxy serves for a single module version.
+func (p *GoProxy) FetchModFile(modPath, version string) (*modfile.File, error) {
+	escaped, err := module.EscapePath(modPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to escape module path: %w", err)
+	}
+	escapedVersion, err := module.EscapeVersion(version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to escape module version: %w", err)
+	}
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet,
+		fmt.Sprintf("%s/%s/@v/%s.mod", p.baseURL, escaped, escapedVersion), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	setDefaultHTTPHeaders(req)
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch go.mod: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch go.mod: %s", resp.Status)
+	}
+	buf, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read go.mod: %w", err)
+	}
+	mod, err := modfile.ParseLax(modPath+"@"+version+"/go.mod", buf, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse go.mod: %w", err)
+	}
+	return mod, nil
+}
+
+// maxModuleZipSize is the largest module zip the go command accepts.
+const maxModuleZipSize = 500 << 20
+
+// FetchZip returns the module zip the module proxy serves for a single module version.
+func (p *GoProxy) FetchZip(modPath, version string) ([]byte, error) {
+	escaped, err := module.EscapePath(modPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to escape module path: %w", err)
+	}
+	escapedVersion, err := module.EscapeVersion(version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to escape module version: %w", err)
+	}
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet,
+		fmt.Sprintf("%s/%s/@v/%s.zip", p.baseURL, escaped, escapedVersion), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	setDefaultHTTPHeaders(req)
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch module zip: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch module zip: %s", resp.Status)
+	}
+	buf, err := io.ReadAll(io.LimitReader(resp.Body, maxModuleZipSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read module zip: %w", err)
+	}
+	if len(buf) > maxModuleZipSize {
+		return nil, fmt.Errorf("module zip larger than %d bytes", maxModuleZipSize)
+	}
+	return buf, nil
+}
+
 // discardBody drains and closes a response body when the caller does not need it.
 func discardBody(resp *http.Response) {
 	io.Copy(io.Discard, resp.Body)
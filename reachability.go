@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"go/types"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/tools/go/callgraph/cha"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// loadMode loads everything the SSA builder needs, for the main module and its dependencies.
+const loadMode = packages.NeedName | packages.NeedFiles | packages.NeedImports | packages.NeedDeps |
+	packages.NeedTypes | packages.NeedSyntax | packages.NeedTypesInfo | packages.NeedModule
+
+// symbolName returns the OSV symbol of a function, e.g. Parse or Template.Execute,
+// together with its package path; ok is false for closures and wrappers.
+func symbolName(fn *ssa.Function) (pkgPath, symbol string, ok bool) {
+	if origin := fn.Origin(); origin != nil {
+		fn = origin
+	}
+	obj, isFunc := fn.Object().(*types.Func)
+	if !isFunc || obj.Pkg() == nil {
+		return "", "", false
+	}
+	symbol = obj.Name()
+	if recv := fn.Signature.Recv(); recv != nil {
+		t := recv.Type()
+		if p, isPtr := t.(*types.Pointer); isPtr {
+			t = p.Elem()
+		}
+		named, isNamed := types.Unalias(t).(*types.Named)
+		if !isNamed {
+			return "", "", false
+		}
+		symbol = named.Obj().Name() + "." + symbol
+	}
+	return obj.Pkg().Path(), symbol, true
+}
+
+// callGraphSymbols loads the packages of the main module (with tests) and
+// returns the symbols reachable from any of its functions in a class
+// hierarchy analysis call graph, keyed "package.Symbol", and every imported package.
+func callGraphSymbols() (map[string]bool, map[string]bool, error) {
+	cfg := &packages.Config{Mode: loadMode, Tests: true, Env: subprocessEnv()}
+	pkgs, err := packages.Load(cfg, "./...")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load packages: %w", err)
+	}
+	imported := map[string]bool{}
+	var loadErr error
+	packages.Visit(pkgs, nil, func(p *packages.Package) {
+		imported[p.PkgPath] = true
+		if len(p.Errors) > 0 && loadErr == nil {
+			loadErr = fmt.Errorf("failed to load package %s: %v", p.PkgPath, p.Errors[0])
+		}
+	})
+	if loadErr != nil {
+		return nil, nil, loadErr
+	}
+
+	prog, initial := ssautil.AllPackages(pkgs, ssa.InstantiateGenerics)
+	prog.Build()
+	roots := map[*ssa.Package]bool{}
+	for _, p := range initial {
+		if p != nil {
+			roots[p] = true
+		}
+	}
+	cg := cha.CallGraph(prog)
+
+	var queue []*ssa.Function
+	seen := map[*ssa.Function]bool{}
+	for fn := range ssautil.AllFunctions(prog) {
+		if roots[fn.Pkg] {
+			queue = append(queue, fn)
+			seen[fn] = true
+		}
+	}
+	symbols := map[string]bool{}
+	for len(queue) > 0 {
+		fn := queue[0]
+		queue = queue[1:]
+		if pkgPath, symbol, ok := symbolName(fn); ok {
+			symbols[pkgPath+"."+symbol] = true
+		}
+		node := cg.Nodes[fn]
+		if node == nil {
+			continue
+		}
+		for _, e := range node.Out {
+			if callee := e.Callee.Func; !seen[callee] {
+				seen[callee] = true
+				queue = append(queue, callee)
+			}
+		}
+	}
+	return symbols, imported, nil
+}
+
+// advisoryReachable reports whether the main module can reach the code of an
+// advisory for modulePath: one of its symbols, or an affected package when no
+// symbols are listed, or any package of the module when no packages are listed.
+func advisoryReachable(e *osvEntry, modulePath string, symbols, imported map[string]bool) bool {
+	for _, a := range e.Affected {
+		if a.Package.Name != modulePath {
+			continue
+		}
+		if len(a.EcosystemSpecific.Imports) == 0 {
+			for pkg := range imported {
+				if pkg == modulePath || strings.HasPrefix(pkg, modulePath+"/") {
+					return true
+				}
+			}
+		}
+		for _, imp := range a.EcosystemSpecific.Imports {
+			if len(imp.Symbols) == 0 && imported[imp.Path] {
+				return true
+			}
+			for _, s := range imp.Symbols {
+				if symbols[imp.Path+"."+s] {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// analyzeReachability records in vulnDB which advisories affecting the
+// requirements of mod are reachable from the main module.
+func analyzeReachability(mod *modfile.File) error {
+	vulnDB.reachable = map[string]bool{}
+	type affected struct {
+		entry      *osvEntry
+		modulePath string
+	}
+	var check []affected
+	for _, r := range mod.Require {
+		for _, e := range vulnDB.advisories(r.Mod.Path, r.Mod.Version) {
+			check = append(check, affected{entry: e, modulePath: r.Mod.Path})
+		}
+	}
+	if len(check) == 0 {
+		return nil
+	}
+	symbols, imported, err := callGraphSymbols()
+	if err != nil {
+		return err
+	}
+	for _, c := range check {
+		vulnDB.reachable[c.entry.ID] = vulnDB.reachable[c.entry.ID] || advisoryReachable(c.entry, c.modulePath, symbols, imported)
+	}
+	return nil
+}
+
+// securityAdvisories returns the advisories -security acts on for modulePath
+// at version: all of them, or only reachable ones with -reachable-only.
+func securityAdvisories(modulePath, version string) []*osvEntry {
+	entries := vulnDB.advisories(modulePath, version)
+	if !config.ReachableOnly {
+		return entries
+	}
+	var result []*osvEntry
+	for _, e := range entries {
+		if vulnDB.reachable[e.ID] {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// hasReachableAdvisory reports whether modulePath at version is affected by a reachable advisory.
+func hasReachableAdvisory(modulePath, version string) bool {
+	for _, e := range vulnDB.advisories(modulePath, version) {
+		if vulnDB.reachable[e.ID] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/mod/modfile"
+)
+
+var reachabilityProject = map[string]string{
+	"go.mod": `module example.com/app
+
+go 1.22
+
+require example.com/lib v1.0.0
+
+replace example.com/lib => ./lib
+`,
+	"main.go": `package main
+
+import "example.com/lib/parse"
+
+func main() {
+	var d parse.Decoder
+	d.Decode("x")
+}
+`,
+	"lib/go.mod": `module example.com/lib
+
+go 1.22
+`,
+	"lib/parse/parse.go": `package parse
+
+type Decoder struct{}
+
+func (d *Decoder) Decode(s string) string { return helper(s) }
+
+func helper(s string) string { return s }
+
+func Unsafe(s string) string { return s }
+`,
+	"lib/unused/unused.go": `package unused
+
+func Do() {}
+`,
+}
+
+func reachabilityAdvisory(id, imports string) string {
+	return `{"id":"` + id + `","affected":[{"package":{"name":"example.com/lib","ecosystem":"Go"},"ranges":[{"type":"SEMVER","events":[{"introduced":"0"},{"fixed":"1.1.0"}]}],"ecosystem_specific":{"imports":[` + imports + `]}}]}`
+}
+
+func TestAnalyzeReachability(t *testing.T) {
+	project := t.TempDir()
+	for name, content := range reachabilityProject {
+		file := filepath.Join(project, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	db := &vulnDatabase{byModule: map[string][]*osvEntry{}}
+	advisories := map[string]string{
+		"GO-2024-0010": `{"path":"example.com/lib/parse","symbols":["Decoder.Decode"]}`,
+		"GO-2024-0011": `{"path":"example.com/lib/parse","symbols":["helper"]}`,
+		"GO-2024-0012": `{"path":"example.com/lib/parse","symbols":["Unsafe"]}`,
+		"GO-2024-0013": `{"path":"example.com/lib/parse"}`,
+		"GO-2024-0014": `{"path":"example.com/lib/unused"}`,
+		"GO-2024-0015": ``,
+	}
+	for id, imports := range advisories {
+		if err := db.add("ID/"+id+".json", strings.NewReader(reachabilityAdvisory(id, imports))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	oldDB := vulnDB
+	vulnDB = db
+	t.Cleanup(func() { vulnDB = oldDB })
+
+	oldWd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(oldWd) })
+	if err := os.Chdir(project); err != nil {
+		t.Fatal(err)
+	}
+	mod, err := modfile.Parse("go.mod", []byte(reachabilityProject["go.mod"]), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := analyzeReachability(mod); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]bool{
+		"GO-2024-0010": true,
+		"GO-2024-0011": true,
+		"GO-2024-0012": false,
+		"GO-2024-0013": true,
+		"GO-2024-0014": false,
+		"GO-2024-0015": true,
+	}
+	if diff := cmp.Diff(want, db.reachable); diff != "" {
+		t.Errorf("reachable (-want +got):\n%s", diff)
+	}
+}
+
+func TestSecurityAdvisoriesReachableOnly(t *testing.T) {
+	db := &vulnDatabase{byModule: map[string][]*osvEntry{}}
+	for _, id := range []string{"GO-2024-0010", "GO-2024-0012"} {
+		if err := db.add("ID/"+id+".json", strings.NewReader(reachabilityAdvisory(id, ""))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.reachable = map[string]bool{"GO-2024-0010": true, "GO-2024-0012": false}
+	oldDB := vulnDB
+	vulnDB = db
+	t.Cleanup(func() { vulnDB = oldDB })
+	config = &AppConfig{}
+
+	ids := func() []string {
+		var result []string
+		for _, e := range securityAdvisories("example.com/lib", "v1.0.0") {
+			result = append(result, e.ID)
+		}
+		return result
+	}
+	if diff := cmp.Diff([]string{"GO-2024-0010", "GO-2024-0012"}, ids()); diff != "" {
+		t.Errorf("all advisories (-want +got):\n%s", diff)
+	}
+	config.ReachableOnly = true
+	if diff := cmp.Diff([]string{"GO-2024-0010"}, ids()); diff != "" {
+		t.Errorf("reachable advisories (-want +got):\n%s", diff)
+	}
+	if !hasReachableAdvisory("example.com/lib", "v1.0.0") || hasReachableAdvisory("example.com/lib", "v1.1.0") {
+		t.Error("hasReachableAdvisory mismatch")
+	}
+	if got := db.advisory(db.byModule["example.com/lib"][1]); got.String() != "GO-2024-0012 [unreachable]" {
+		t.Errorf("advisory = %q", got)
+	}
+}
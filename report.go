@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"golang.org/x/mod/modfile"
+)
+
+// runReport runs a read-only report subcommand and renders it through out,
+// and as JSON when -json is set.
+func runReport(original *modfile.File) {
+	var report any
+	switch config.Command {
+	case commandOutdated:
+		pinned, outdated := outdatedReport(original)
+		out.PrintOutdated(pinned, outdated)
+		report = outdated
+	case commandMatrix:
+		matrix := compatibilityMatrix(original)
+		out.PrintMatrix(matrix)
+		report = matrix
+	case commandExplain:
+		if len(config.Dependencies) != 1 {
+			out.Fatal("usage: gobump [flags] explain MODULE", ERR_ARGS)
+			return
+		}
+		explanation := explainModule(original, config.Dependencies[0])
+		out.PrintExplain(explanation)
+		report = explanation
+	}
+	if err := writeReportJSON(report); err != nil {
+		out.Fatal(err.Error(), ERR_WRITE)
+	}
+}
+
+// writeReportJSON writes a report as indented JSON to the -json destination ("-" for stdout).
+func writeReportJSON(report any) error {
+	if config.JSON == "" {
+		return nil
+	}
+	buf, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding JSON report: %w", err)
+	}
+	buf = append(buf, '\n')
+	if config.JSON == "-" {
+		_, err = os.Stdout.Write(buf)
+		return err
+	}
+	if err := os.WriteFile(config.JSON, buf, 0644); err != nil {
+		return fmt.Errorf("error writing JSON report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// RequireChange is a require directive that differs between two go.mod files.
+// An empty VersionBefore means the requirement was added, an empty VersionAfter that it was removed.
+type RequireChange struct {
+	ModulePath    string
+	VersionBefore string
+	VersionAfter  string
+}
+
+func (c RequireChange) String() string {
+	switch {
+	case c.VersionBefore == "":
+		return c.ModulePath + " added " + c.VersionAfter
+	case c.VersionAfter == "":
+		return c.ModulePath + " removed " + c.VersionBefore
+	}
+	return c.ModulePath + " " + c.VersionBefore + " -> " + c.VersionAfter
+}
+
+// Downgrade reports whether the requirement moved to a lower version.
+func (c RequireChange) Downgrade() bool {
+	return c.VersionBefore != "" && c.VersionAfter != "" && semver.Compare(c.VersionAfter, c.VersionBefore) < 0
+}
+
+// diffRequires returns the requirement changes from oldMod to newMod sorted by module path,
+// leaving out skipPath (the module being bumped).
+func diffRequires(oldMod, newMod *modfile.File, skipPath string) []RequireChange {
+	before := map[string]string{}
+	for _, r := range oldMod.Require {
+		before[r.Mod.Path] = r.Mod.Version
+	}
+	after := map[string]string{}
+	for _, r := range newMod.Require {
+		after[r.Mod.Path] = r.Mod.Version
+	}
+
+	var changes []RequireChange
+	for path, v := range before {
+		if path != skipPath && after[path] != v {
+			changes = append(changes, RequireChange{ModulePath: path, VersionBefore: v, VersionAfter: after[path]})
+		}
+	}
+	for path, v := range after {
+		if _, ok := before[path]; !ok && path != skipPath {
+			changes = append(changes, RequireChange{ModulePath: path, VersionAfter: v})
+		}
+	}
+	slices.SortFunc(changes, func(a, b RequireChange) int {
+		return strings.Compare(a.ModulePath, b.ModulePath)
+	})
+	return changes
+}
+
+// validateRequireChanges rejects side effects of bumping modulePath that downgrade
+// another requirement or touch a module excluded with -exclude.
+func validateRequireChanges(okMod, newMod *modfile.File, modulePath string) error {
+	for _, c := range diffRequires(okMod, newMod, modulePath) {
+		if excludedBy(module.Version{Path: c.ModulePath, Version: c.VersionBefore}) != "" {
+			return fmt.Errorf("upgrade changes excluded module %s", c)
+		}
+		if c.Downgrade() {
+			return fmt.Errorf("upgrade downgrades %s", c)
+		}
+	}
+	return nil
+}
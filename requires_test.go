@@ -0,0 +1,70 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/mod/modfile"
+)
+
+func TestDiffRequires(t *testing.T) {
+	oldMod, err := modfile.Parse("go.mod", []byte(`module example.com/m
+
+go 1.22
+
+require (
+	example.com/bumped v1.0.0
+	example.com/down v1.2.0
+	example.com/gone v1.0.0
+	example.com/same v1.0.0
+	example.com/up v1.0.0 // indirect
+)
+`), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newMod, err := modfile.Parse("go.mod", []byte(`module example.com/m
+
+go 1.22
+
+require (
+	example.com/added v0.1.0 // indirect
+	example.com/bumped v1.1.0
+	example.com/down v1.1.0
+	example.com/same v1.0.0
+	example.com/up v1.3.0 // indirect
+)
+`), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes := diffRequires(oldMod, newMod, "example.com/bumped")
+	want := []RequireChange{
+		{ModulePath: "example.com/added", VersionAfter: "v0.1.0"},
+		{ModulePath: "example.com/down", VersionBefore: "v1.2.0", VersionAfter: "v1.1.0"},
+		{ModulePath: "example.com/gone", VersionBefore: "v1.0.0"},
+		{ModulePath: "example.com/up", VersionBefore: "v1.0.0", VersionAfter: "v1.3.0"},
+	}
+	if diff := cmp.Diff(want, changes); diff != "" {
+		t.Errorf("diffRequires mismatch (-want +got):\n%s", diff)
+	}
+
+	config = &AppConfig{}
+	if err := validateRequireChanges(oldMod, newMod, "example.com/bumped"); err == nil || err.Error() != "upgrade downgrades example.com/down v1.2.0 -> v1.1.0" {
+		t.Errorf("expected downgrade error, got %v", err)
+	}
+
+	config = &AppConfig{Exclude: commaSeparatedStringSlice{"example.com/up"}}
+	if err := validateSelectors(); err != nil {
+		t.Fatal(err)
+	}
+	if err := validateRequireChanges(oldMod, oldMod, "example.com/bumped"); err != nil {
+		t.Errorf("expected no error without changes, got %v", err)
+	}
+	newMod.DropRequire("example.com/down")
+	newMod.AddNewRequire("example.com/down", "v1.2.0", false)
+	if err := validateRequireChanges(oldMod, newMod, "example.com/bumped"); err == nil || err.Error() != "upgrade changes excluded module example.com/up v1.0.0 -> v1.3.0" {
+		t.Errorf("expected excluded module error, got %v", err)
+	}
+}
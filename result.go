@@ -6,7 +6,55 @@ type Result struct {
 	VersionBefore   string
 	VersionAfter    string
 	Excluded        bool
-	NoProxyVersions bool // proxy returned no semver newer than current (no go get attempted)
+	ExcludedBy      string // the -exclude selector that matched
+	NoProxyVersions bool   // proxy returned no semver newer than current (no go get attempted)
+	// TransitiveChanges are the accepted changes to other requirements made by the bump.
+	TransitiveChanges []RequireChange
+	// UnblockedByGo is the Go version above the original go directive the update
+	// required, set when it only became possible with -target-go.
+	UnblockedByGo string
+	// HeldBack is the newest version on the module proxy when a constraint
+	// (HeldBy) ruled it out.
+	HeldBack string
+	HeldBy   string
+	// Reason is the configured reason for excluding or constraining the module.
+	Reason string
+	// Fixes are the advisories from -vulndb affecting VersionBefore but not VersionAfter.
+	Fixes []Advisory
+	// OpenAdvisories are the advisories from -vulndb still affecting VersionAfter.
+	OpenAdvisories []Advisory
+	// APIChanges are the incompatible changes of the exported API between
+	// VersionBefore and VersionAfter, with -apidiff.
+	APIChanges []string
+	// LicenseIssues are the license changes and licenses outside -license-allow
+	// found by -license-check: of VersionAfter, or of LicenseBlocked when set.
+	LicenseIssues []string
+	// LicenseBlocked is the newest version -license-check block rejected.
+	LicenseBlocked string
+	// ModulesAdded and ModulesRemoved are the module paths the bump added to
+	// or removed from the module graph; GoSumAdded and GoSumRemoved count the
+	// go.sum lines it added and removed.
+	ModulesAdded   []string
+	ModulesRemoved []string
+	GoSumAdded     int
+	GoSumRemoved   int
+	// CgoPackages are the imported packages needing cgo since VersionAfter,
+	// or since CgoBlocked, the newest version -cgo-check block rejected.
+	CgoPackages []string
+	CgoBlocked  string
+	// BinarySizes are the -size-package binary sizes before and after the bump.
+	BinarySizes []BinarySize
+	// Benchmarks compare the -bench-package benchmarks before and after the
+	// bump to VersionAfter, or to BenchBlocked, the newest version rejected by
+	// -max-bench-regression.
+	Benchmarks   []BenchComparison
+	BenchBlocked string
+	// TestFailures are the -test-package tests that failed with each rejected
+	// candidate version, leaving out tests that failed before any bump.
+	TestFailures []TestFailure
+	// Retries are the -exec commands and -test-package tests that failed and
+	// then passed when re-run with -exec-retries, per candidate version.
+	Retries []Retry
 }
 
 // resultsHaveErrors reports whether any module that was considered for update
@@ -0,0 +1,50 @@
+package main
+
+import "fmt"
+
+// Retry is an -exec command or -test-package test that failed with a
+// candidate version and passed when re-run with -exec-retries.
+type Retry struct {
+	Version string
+	Check   string // -exec command, or package.Test
+	Runs    int    // runs until it passed
+}
+
+func (r Retry) String() string {
+	return fmt.Sprintf("%s passed on run %d", r.Check, r.Runs)
+}
+
+// validateExecRetries checks the -exec-retries value.
+func validateExecRetries() error {
+	if config.ExecRetries < 0 {
+		return fmt.Errorf("invalid -exec-retries %d, must not be negative", config.ExecRetries)
+	}
+	return nil
+}
+
+// runWithRetries runs an -exec command and re-runs it up to retries times
+// while it fails other than known, its failure before any bump, if any. It
+// returns the number of runs and how the last one failed, nil when it passed.
+func runWithRetries(ws *workspace, c string, retries int, known *commandFailure) (int, *commandFailure) {
+	run := func() *commandFailure {
+		if output, err := ws.cmdsOutput(c); err != nil {
+			return newCommandFailure(ws, output, err)
+		}
+		return nil
+	}
+	runs := 1
+	failure := run()
+	for ; failure != nil && (known == nil || *failure != *known) && runs <= retries; runs++ {
+		ws.Out.Println(fmt.Sprintf("command failed, re-running it (run %d of %d)", runs+1, retries+1))
+		failure = run()
+	}
+	return runs, failure
+}
+
+// addRetries records the retries a candidate version needed.
+func (e *candidateEvaluation) addRetries(version string, retries []Retry) {
+	for _, r := range retries {
+		r.Version = version
+		e.retries = append(e.retries, r)
+	}
+}
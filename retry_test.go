@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// writeFiles writes files given by slash-separated paths relative to dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRunCommandsRetries(t *testing.T) {
+	dir := t.TempDir()
+	// flaky.sh fails on its first run only.
+	writeFiles(t, dir, map[string]string{"flaky.sh": "[ -f ran ] && exit 0\ntouch ran\nexit 1\n"})
+	config = &AppConfig{Commands: stringSlice{"true", "sh flaky.sh"}}
+	ws := &workspace{Dir: dir, Out: &OutputNone{}}
+
+	if _, ok := runCommands(ws, nil); ok {
+		t.Error("runCommands succeeded without -exec-retries")
+	}
+	if err := os.Remove(filepath.Join(dir, "ran")); err != nil {
+		t.Fatal(err)
+	}
+	config.ExecRetries = 2
+	retries, ok := runCommands(ws, nil)
+	if !ok {
+		t.Error("runCommands failed with -exec-retries")
+	}
+	if diff := cmp.Diff([]Retry{{Check: "sh flaky.sh", Runs: 2}}, retries); diff != "" {
+		t.Errorf("retries mismatch (-want +got):\n%s", diff)
+	}
+	if runs, failure := runWithRetries(ws, "false", 2, nil); failure == nil || runs != 3 {
+		t.Errorf("runWithRetries = %d, %v; want 3 failed runs", runs, failure)
+	}
+	if runs, failure := runWithRetries(ws, "false", 2, &commandFailure{status: 1}); failure == nil || runs != 1 {
+		t.Errorf("runWithRetries = %d, %v; want 1 run failing like before any bump", runs, failure)
+	}
+}
+
+func TestRunTestGateRetries(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod": "module example.com/app\n\ngo 1.22\n",
+		"a/a_test.go": `package a
+
+import (
+	"os"
+	"testing"
+)
+
+func TestFlaky(t *testing.T) {
+	t.Run("once", func(t *testing.T) {
+		if _, err := os.Stat("ran"); err != nil {
+			os.WriteFile("ran", nil, 0o644)
+			t.Fatal("flake")
+		}
+	})
+}
+
+func TestBroken(t *testing.T) { t.Fatal("always") }
+
+func TestOK(t *testing.T) {}
+`,
+	})
+	config = &AppConfig{GoBinary: "go", TestPackages: []string{"./..."}, ExecRetries: 2}
+	ws := &workspace{Dir: dir, Out: &OutputNone{}}
+	failed, retries, err := runTestGate(ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"example.com/app/a.TestBroken"}, failed); diff != "" {
+		t.Errorf("failed mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]Retry{{Check: "example.com/app/a.TestFlaky/once", Runs: 2}}, retries); diff != "" {
+		t.Errorf("retries mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestOutputMarkdownPrintRetries(t *testing.T) {
+	var buf bytes.Buffer
+	out := NewOutputMarkdown(&buf)
+	out.printRetries([]Result{
+		{ModulePath: "example.com/mod", Success: true, VersionBefore: "v1.0.0", VersionAfter: "v1.1.0",
+			Retries: []Retry{{Version: "v1.1.0", Check: "go test ./...", Runs: 2}, {Version: "v1.2.0", Check: "example.com/app/a.TestFlaky", Runs: 3}}},
+		{ModulePath: "example.com/ok", Success: true, VersionBefore: "v1.0.0", VersionAfter: "v1.1.0"},
+	})
+	expected := "\n### Flaky checks\n\n" +
+		"* `example.com/mod`\n" +
+		"  * v1.1.0: `go test ./...` passed on run 2\n" +
+		"  * v1.2.0: `example.com/app/a.TestFlaky` passed on run 3\n"
+	if diff := cmp.Diff(expected, buf.String()); diff != "" {
+		t.Errorf("printRetries mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"golang.org/x/mod/module"
+)
+
+// selector matches modules by path and, optionally, version. Syntax:
+//
+//	golang.org/x/mod            exact module path
+//	golang.org/x/*              path glob (path.Match, * does not cross /)
+//	github.com/aws/...          the path and every module path below it
+//	!github.com/aws/...         negation, see selectorList
+//	github.com/foo/bar@<v2.0.0  version constraint (<, <=, >, >=, = or a bare version)
+type selector struct {
+	raw     string
+	negate  bool
+	pattern string
+	version *versionComparison // nil when there is no version constraint
+}
+
+// parseSelector parses a single selector expression.
+func parseSelector(s string) (selector, error) {
+	sel := selector{raw: s}
+	rest := strings.TrimSpace(s)
+	if strings.HasPrefix(rest, "!") {
+		sel.negate = true
+		rest = rest[1:]
+	}
+	pattern, constraint, hasVersion := strings.Cut(rest, "@")
+	sel.pattern = pattern
+	if pattern == "" {
+		return sel, fmt.Errorf("selector %q: empty module path", s)
+	}
+	if _, err := path.Match(strings.TrimSuffix(pattern, "/..."), ""); err != nil {
+		return sel, fmt.Errorf("selector %q: %w", s, err)
+	}
+	if hasVersion {
+		vc, err := parseVersionComparison(constraint)
+		if err != nil {
+			return sel, fmt.Errorf("selector %q: %w", s, err)
+		}
+		sel.version = &vc
+	}
+	return sel, nil
+}
+
+func (s selector) String() string {
+	return s.raw
+}
+
+// matchesPath reports whether the module path matches the selector pattern, ignoring negation.
+func (s selector) matchesPath(modulePath string) bool {
+	if prefix, ok := strings.CutSuffix(s.pattern, "/..."); ok {
+		if modulePath == prefix || strings.HasPrefix(modulePath, prefix+"/") {
+			return true
+		}
+		ok, _ := path.Match(prefix, modulePath)
+		return ok
+	}
+	ok, _ := path.Match(s.pattern, modulePath)
+	return ok
+}
+
+// matchesVersion reports whether version satisfies the version constraint, if any.
+func (s selector) matchesVersion(version string) bool {
+	return s.version == nil || s.version.allows(version)
+}
+
+// selectorList is an ordered list of selectors. The last selector matching a
+// module decides: a plain one selects it, a negated one deselects it. A list
+// of negations only starts from all modules selected; validateSelectors only
+// allows that for the dependency arguments.
+type selectorList []selector
+
+// parseSelectors parses every expression and returns the first error.
+func parseSelectors(exprs []string) (selectorList, error) {
+	var list selectorList
+	for _, e := range exprs {
+		if strings.TrimSpace(e) == "" {
+			continue
+		}
+		sel, err := parseSelector(e)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, sel)
+	}
+	return list, nil
+}
+
+// negationsOnly reports whether the list has selectors and all of them are negations.
+func (l selectorList) negationsOnly() bool {
+	for _, sel := range l {
+		if !sel.negate {
+			return false
+		}
+	}
+	return len(l) > 0
+}
+
+// match reports whether mod is selected and returns the deciding selector.
+// An empty list selects nothing.
+func (l selectorList) match(mod module.Version) (bool, *selector) {
+	var decider *selector
+	selected := false
+	for i := range l {
+		if !l[i].negate {
+			break
+		}
+		if i == len(l)-1 {
+			selected, decider = true, &l[0]
+		}
+	}
+	for i := range l {
+		if l[i].matchesPath(mod.Path) && l[i].matchesVersion(mod.Version) {
+			selected, decider = !l[i].negate, &l[i]
+		}
+	}
+	return selected, decider
+}
+
+// holdAnnotation is reported as the selector of modules excluded by a gobump:hold annotation.
+const holdAnnotation = annotationPrefix + "hold"
+
+// excludedBy returns the -exclude selector that excludes mod, holdAnnotation,
+// or "" when the module is not excluded.
+func excludedBy(mod module.Version) string {
+	if config.Modules[mod.Path].Hold {
+		return holdAnnotation
+	}
+	if ok, sel := config.ExcludeSelectors.match(mod); ok {
+		return sel.String()
+	}
+	return ""
+}
+
+// validateSelectors parses -exclude, -hold, -deny-module and the positional dependencies.
+func validateSelectors() error {
+	var err error
+	for _, l := range []struct {
+		flag  string
+		exprs []string
+		dst   *selectorList
+	}{
+		{"exclude", config.Exclude, &config.ExcludeSelectors},
+		{"hold", config.Hold, &config.HoldSelectors},
+		{"deny-module", config.DenyModules, &config.DenySelectors},
+	} {
+		if *l.dst, err = parseSelectors(l.exprs); err != nil {
+			return fmt.Errorf("invalid -%s: %w", l.flag, err)
+		}
+		// A negation-only list would match every other module.
+		if l.dst.negationsOnly() {
+			return fmt.Errorf("invalid -%s %s: negations only remove modules matched by an earlier selector", l.flag, strings.Join(l.exprs, ","))
+		}
+	}
+	if config.Command == commandExplain || config.Command == commandConfig {
+		// These subcommands take other positional arguments.
+		return nil
+	}
+	if config.IncludeSelectors, err = parseSelectors(config.Dependencies); err != nil {
+		return fmt.Errorf("invalid dependency argument: %w", err)
+	}
+	return nil
+}
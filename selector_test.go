@@ -0,0 +1,86 @@
+package main
+
+import (
+	"testing"
+
+	"golang.org/x/mod/module"
+)
+
+func TestParseSelector(t *testing.T) {
+	for _, expr := range []string{"", "!", "example.com/[", "example.com/a@1.0", "example.com/a@<"} {
+		if _, err := parseSelector(expr); err == nil {
+			t.Errorf("parseSelector(%q): expected error", expr)
+		}
+	}
+	sel, err := parseSelector("!github.com/foo/bar@<=v2.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sel.negate || sel.pattern != "github.com/foo/bar" || *sel.version != (versionComparison{op: "<=", version: "v2.0.0"}) {
+		t.Errorf("unexpected selector %+v", sel)
+	}
+}
+
+func TestSelectorListMatch(t *testing.T) {
+	tests := []struct {
+		exprs   []string
+		path    string
+		version string
+		want    bool
+		decider string
+	}{
+		{nil, "golang.org/x/mod", "v0.1.0", false, ""},
+		{[]string{"golang.org/x/mod"}, "golang.org/x/mod", "v0.1.0", true, "golang.org/x/mod"},
+		{[]string{"golang.org/x/*"}, "golang.org/x/mod", "v0.1.0", true, "golang.org/x/*"},
+		{[]string{"golang.org/x/*"}, "golang.org/x/mod/v2", "v2.0.0", false, ""},
+		{[]string{"github.com/aws/..."}, "github.com/aws/aws-sdk-go-v2/service/s3", "v1.0.0", true, "github.com/aws/..."},
+		{[]string{"github.com/aws/..."}, "github.com/aws", "v1.0.0", true, "github.com/aws/..."},
+		{[]string{"github.com/aws/..."}, "github.com/awslabs/x", "v1.0.0", false, ""},
+		{[]string{"github.com/foo/bar@<v2.0.0"}, "github.com/foo/bar", "v1.9.0", true, "github.com/foo/bar@<v2.0.0"},
+		{[]string{"github.com/foo/bar@<v2.0.0"}, "github.com/foo/bar", "v2.0.0", false, ""},
+		{[]string{"github.com/foo/bar@v1.2.3"}, "github.com/foo/bar", "v1.2.3", true, "github.com/foo/bar@v1.2.3"},
+		{[]string{"!github.com/aws/*"}, "github.com/aws/smithy-go", "v1.0.0", false, "!github.com/aws/*"},
+		{[]string{"!github.com/aws/*"}, "golang.org/x/mod", "v0.1.0", true, "!github.com/aws/*"},
+		{[]string{"github.com/*/*", "!github.com/aws/*"}, "github.com/aws/smithy-go", "v1.0.0", false, "!github.com/aws/*"},
+		{[]string{"github.com/*/*", "!github.com/aws/*"}, "github.com/foo/bar", "v1.0.0", true, "github.com/*/*"},
+		{[]string{"github.com/*/*", "!github.com/aws/*"}, "golang.org/x/mod", "v0.1.0", false, ""},
+	}
+	for _, tt := range tests {
+		list, err := parseSelectors(tt.exprs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, decider := list.match(module.Version{Path: tt.path, Version: tt.version})
+		name := ""
+		if decider != nil {
+			name = decider.String()
+		}
+		if got != tt.want || name != tt.decider {
+			t.Errorf("%v match %s@%s = %v (%q), want %v (%q)", tt.exprs, tt.path, tt.version, got, name, tt.want, tt.decider)
+		}
+	}
+}
+
+func TestValidateSelectorsNegationsOnly(t *testing.T) {
+	tests := []struct {
+		config  AppConfig
+		wantErr string
+	}{
+		{AppConfig{Exclude: commaSeparatedStringSlice{"!github.com/aws/*"}}, "invalid -exclude !github.com/aws/*: negations only remove modules matched by an earlier selector"},
+		{AppConfig{Hold: commaSeparatedStringSlice{"!example.com/a"}}, "invalid -hold !example.com/a: negations only remove modules matched by an earlier selector"},
+		{AppConfig{DenyModules: commaSeparatedStringSlice{"!example.com/a", "!example.com/b"}}, "invalid -deny-module !example.com/a,!example.com/b: negations only remove modules matched by an earlier selector"},
+		{AppConfig{Exclude: commaSeparatedStringSlice{"github.com/*/*", "!github.com/aws/*"}}, ""},
+		{AppConfig{Dependencies: []string{"!github.com/aws/*"}}, ""},
+	}
+	for _, tt := range tests {
+		config = &tt.config
+		err := validateSelectors()
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+		if got != tt.wantErr {
+			t.Errorf("validateSelectors(%+v) = %q, want %q", tt.config, got, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"go/version"
+
+	"golang.org/x/mod/modfile"
+)
+
+// validateTargetGo checks that -target-go is a Go version not older than the go directive.
+func validateTargetGo(mod *modfile.File) error {
+	if config.TargetGo == "" {
+		return nil
+	}
+	if !version.IsValid("go" + config.TargetGo) {
+		return fmt.Errorf("invalid -target-go %q", config.TargetGo)
+	}
+	if mod.Go != nil && version.Compare(goRelease(config.TargetGo), goRelease(mod.Go.Version)) < 0 {
+		return fmt.Errorf("-target-go %s is older than go %s in %s", config.TargetGo, mod.Go.Version, config.GoModSrc)
+	}
+	return nil
+}
+
+// raiseGoDirective sets the go directive to -target-go and, with per-dependency
+// git commits, commits that change on its own before any dependency is bumped.
+func raiseGoDirective(ws *workspace, okMod *modfile.File, perDepGit bool) (*modfile.File, error) {
+	if okMod.Go != nil && goRelease(okMod.Go.Version) == goRelease(config.TargetGo) {
+		return okMod, nil
+	}
+	before := "-"
+	if okMod.Go != nil {
+		before = okMod.Go.Version
+	}
+	ws.Out.Println("raising go directive", before, "=>", config.TargetGo)
+
+	if err := okMod.AddGoStmt(config.TargetGo); err != nil {
+		return nil, fmt.Errorf("failed to set go directive: %w", err)
+	}
+	if err := saveMod(ws.GoModDst, okMod); err != nil {
+		return nil, err
+	}
+	if perDepGit && gitWorktreeDiffersFromHEAD() {
+		if err := gitCommitGoMod(fmt.Sprintf("%s: update go directive to %s", config.CommitPrefix, config.TargetGo)); err != nil {
+			return nil, err
+		}
+	}
+	return parseMod(ws.GoModDst)
+}
+
+// goUnblocked returns the highest Go version above originalGo required by the
+// version a module was updated to or by the transitive requirements the update
+// raised, i.e. the reason the bump only became possible with -target-go.
+func goUnblocked(proxy *GoProxy, r Result, originalGo string) string {
+	check := []RequireChange{{ModulePath: r.ModulePath, VersionAfter: r.VersionAfter}}
+	for _, c := range r.TransitiveChanges {
+		if c.VersionAfter != "" && !c.Downgrade() {
+			check = append(check, c)
+		}
+	}
+
+	unblocked := ""
+	for _, c := range check {
+		mod, err := proxy.FetchModFile(c.ModulePath, c.VersionAfter)
+		if err != nil {
+			out.Error("failed to fetch go.mod of", c.ModulePath+"@"+c.VersionAfter+":", err.Error())
+			continue
+		}
+		if mod.Go == nil || version.Compare(goRelease(mod.Go.Version), goRelease(originalGo)) <= 0 {
+			continue
+		}
+		if unblocked == "" || version.Compare(goRelease(mod.Go.Version), goRelease(unblocked)) > 0 {
+			unblocked = mod.Go.Version
+		}
+	}
+	return unblocked
+}
+
+// markGoUnblocked records on each updated result whether it was unblocked by -target-go.
+func markGoUnblocked(proxy *GoProxy, results []Result, originalGo string) {
+	for i, r := range results {
+		if !r.Success || r.Excluded || r.VersionAfter == r.VersionBefore {
+			continue
+		}
+		results[i].UnblockedByGo = goUnblocked(proxy, r, originalGo)
+	}
+}
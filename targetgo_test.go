@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGoUnblocked(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/example.com/mod/@v/v1.1.0.mod":
+			fmt.Fprintln(w, "module example.com/mod\n\ngo 1.22\n\nrequire example.com/dep v1.5.0")
+		case "/example.com/dep/@v/v1.5.0.mod":
+			fmt.Fprintln(w, "module example.com/dep\n\ngo 1.23.2")
+		case "/example.com/old/@v/v2.0.0.mod":
+			fmt.Fprintln(w, "module example.com/old\n\ngo 1.21")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	config = &AppConfig{}
+	out = &OutputNone{}
+	proxy := NewGoProxy(server.URL)
+
+	got := goUnblocked(proxy, Result{
+		ModulePath:    "example.com/mod",
+		VersionBefore: "v1.0.0",
+		VersionAfter:  "v1.1.0",
+		TransitiveChanges: []RequireChange{
+			{ModulePath: "example.com/dep", VersionBefore: "v1.0.0", VersionAfter: "v1.5.0"},
+		},
+	}, "1.22")
+	if got != "1.23.2" {
+		t.Errorf("goUnblocked = %q, want 1.23.2", got)
+	}
+
+	got = goUnblocked(proxy, Result{ModulePath: "example.com/old", VersionBefore: "v1.0.0", VersionAfter: "v2.0.0"}, "1.22")
+	if got != "" {
+		t.Errorf("goUnblocked = %q, want empty", got)
+	}
+}
+
+func TestRaiseGoDirective(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "go.mod")
+	if err := os.WriteFile(path, []byte("module example.com/m\n\ngo 1.22\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mod, err := parseMod(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config = &AppConfig{TargetGo: "1.23.0", GoModSrc: path}
+	if err := validateTargetGo(mod); err != nil {
+		t.Fatal(err)
+	}
+	ws := &workspace{GoModSrc: path, GoModDst: path, Out: &OutputNone{}}
+	newMod, err := raiseGoDirective(ws, mod, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newMod.Go.Version != "1.23.0" {
+		t.Errorf("go directive = %q, want 1.23.0", newMod.Go.Version)
+	}
+
+	config.TargetGo = "1.21"
+	if err := validateTargetGo(newMod); err == nil {
+		t.Error("expected older -target-go to be rejected")
+	}
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// testEvent is the subset of a go test -json (test2json) event the test gate needs.
+type testEvent struct {
+	Action  string
+	Package string
+	Test    string
+}
+
+// TestFailure lists the tests that failed with a rejected candidate version.
+type TestFailure struct {
+	Version string
+	Tests   []string // package.Test, or package when it failed outside a test
+}
+
+func (f TestFailure) String() string {
+	return f.Version + ": " + strings.Join(f.Tests, ", ")
+}
+
+// failedTest is a failed test, or a package that failed outside any test
+// when Test is empty.
+type failedTest struct {
+	Package string
+	Test    string
+}
+
+func (f failedTest) String() string {
+	if f.Test == "" {
+		return f.Package
+	}
+	return f.Package + "." + f.Test
+}
+
+// parseTestEvents reads go test -json output and returns the failed tests
+// sorted by name. Packages failing outside any test (build errors, panics in
+// TestMain, timeouts) are returned without a test; tests whose failure is
+// explained by a failed subtest are left out. Lines that are not JSON, such
+// as build output of older Go versions, are skipped.
+func parseTestEvents(buf []byte) ([]failedTest, error) {
+	failed := map[failedTest]bool{}
+	packageFailed := map[string]bool{}
+	testFailed := map[string]bool{}
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if !bytes.HasPrefix(line, []byte("{")) {
+			continue
+		}
+		var e testEvent
+		if err := json.Unmarshal(line, &e); err != nil {
+			return nil, fmt.Errorf("failed to decode go test output: %w", err)
+		}
+		if e.Action != "fail" {
+			continue
+		}
+		if e.Test == "" {
+			packageFailed[e.Package] = true
+		} else {
+			failed[failedTest{e.Package, e.Test}] = true
+			testFailed[e.Package] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	for pkg := range packageFailed {
+		if !testFailed[pkg] {
+			failed[failedTest{Package: pkg}] = true
+		}
+	}
+	hasFailedSubtest := func(f failedTest) bool {
+		for other := range failed {
+			if other.Package == f.Package && strings.HasPrefix(other.Test, f.Test+"/") {
+				return true
+			}
+		}
+		return false
+	}
+	var result []failedTest
+	for f := range failed {
+		if !hasFailedSubtest(f) {
+			result = append(result, f)
+		}
+	}
+	sortFailedTests(result)
+	return result, nil
+}
+
+func sortFailedTests(tests []failedTest) {
+	slices.SortFunc(tests, func(a, b failedTest) int {
+		return strings.Compare(a.String(), b.String())
+	})
+}
+
+// baselineTestFailures are the tests of -test-package that failed on the tree
+// before any bump with -baseline tolerate; candidates are not rejected for them.
+var baselineTestFailures map[string]bool
+
+// goTestJSON runs go test -json with args in the workspace and returns the
+// failed tests, see parseTestEvents.
+func goTestJSON(ws *workspace, args ...string) ([]failedTest, error) {
+	c := exec.Command(config.GoBinary, append([]string{"test", "-json"}, args...)...)
+	c.Dir = ws.Dir
+	c.Env = subprocessEnv()
+	buf, runErr := c.Output()
+	failed, err := parseTestEvents(buf)
+	if err != nil {
+		return nil, err
+	}
+	if runErr != nil && len(failed) == 0 {
+		var exitErr *exec.ExitError
+		if errors.As(runErr, &exitErr) && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("go test: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("go test: %w", runErr)
+	}
+	return failed, nil
+}
+
+// rerunTests runs the top-level tests of the given failures again, and whole
+// packages for failures outside any test, and returns what fails now.
+func rerunTests(ws *workspace, tests []failedTest) ([]failedTest, error) {
+	var packages []string
+	names := map[string][]string{} // nil: the whole package
+	for _, f := range tests {
+		list, seen := names[f.Package]
+		if !seen {
+			packages = append(packages, f.Package)
+		}
+		if f.Test == "" || (seen && list == nil) {
+			names[f.Package] = nil
+			continue
+		}
+		top, _, _ := strings.Cut(f.Test, "/")
+		if !slices.Contains(list, regexp.QuoteMeta(top)) {
+			names[f.Package] = append(list, regexp.QuoteMeta(top))
+		}
+	}
+	var result []failedTest
+	for _, pkg := range packages {
+		args := []string{pkg}
+		if names[pkg] != nil {
+			args = []string{"-run", "^(" + strings.Join(names[pkg], "|") + ")$", pkg}
+		}
+		failed, err := goTestJSON(ws, args...)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, failed...)
+	}
+	sortFailedTests(result)
+	return result, nil
+}
+
+// runTestGate runs go test -json on the -test-package packages in the
+// workspace and returns the failed test names. With -exec-retries the failed
+// tests that are not baseline failures are re-run up to that many times;
+// the ones passing on a re-run are returned as retries.
+func runTestGate(ws *workspace) ([]string, []Retry, error) {
+	failed, err := goTestJSON(ws, config.TestPackages...)
+	if err != nil {
+		return nil, nil, err
+	}
+	var retries []Retry
+	for run := 2; run <= config.ExecRetries+1; run++ {
+		var known, retry []failedTest
+		for _, f := range failed {
+			if baselineTestFailures[f.String()] {
+				known = append(known, f)
+			} else {
+				retry = append(retry, f)
+			}
+		}
+		if len(retry) == 0 {
+			break
+		}
+		ws.Out.Println(fmt.Sprintf("%d tests failed, re-running them (run %d of %d)", len(retry), run, config.ExecRetries+1))
+		again, err := rerunTests(ws, retry)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, f := range retry {
+			if !slices.Contains(again, f) {
+				retries = append(retries, Retry{Check: f.String(), Runs: run})
+			}
+		}
+		failed = append(known, again...)
+		sortFailedTests(failed)
+	}
+	names := make([]string, len(failed))
+	for i, f := range failed {
+		names[i] = f.String()
+	}
+	return names, retries, nil
+}
+
+// newTestFailures returns the failures that are not in baselineTestFailures.
+func newTestFailures(failed []string) []string {
+	var result []string
+	for _, name := range failed {
+		if !baselineTestFailures[name] {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// checkTests runs the -test-package tests after upgrading to a candidate and
+// returns the tests that fail with it but did not fail before any bump, and
+// the tests that passed only when re-run. It reports whether the version is
+// acceptable; otherwise go.mod is restored to revertTo, unless nil.
+func checkTests(ws *workspace, revertTo *modfile.File) ([]string, []Retry, bool) {
+	ws.Out.BeginPreformatted(config.GoBinary, "test", "-json", strings.Join(config.TestPackages, " "))
+	reject := func(msg string) {
+		if revertTo == nil {
+			ws.Out.Error(msg)
+		} else {
+			ws.Out.Error(msg + "; reverting go.mod")
+			if err := saveMod(ws.GoModDst, revertTo); err != nil {
+				ws.Out.Error("failed to revert go.mod:", err.Error())
+			}
+		}
+		ws.Out.EndPreformatted()
+	}
+	failed, retries, err := runTestGate(ws)
+	if err != nil {
+		reject(err.Error())
+		return nil, nil, false
+	}
+	for _, r := range retries {
+		ws.Out.Println("FLAKY", r.String())
+	}
+	for _, name := range failed {
+		if baselineTestFailures[name] {
+			ws.Out.Println("FAIL", name, "(failed before any bump too, ignoring)")
+		} else {
+			ws.Out.Println("FAIL", name)
+		}
+	}
+	if added := newTestFailures(failed); len(added) > 0 {
+		reject("tests failed")
+		return added, retries, false
+	}
+	ws.Out.EndPreformattedCond(len(failed) > 0 || len(retries) > 0)
+	return nil, retries, true
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseTestEvents(t *testing.T) {
+	buf := []byte(`{"Action":"start","Package":"example.com/app/a"}
+{"Action":"run","Package":"example.com/app/a","Test":"TestOK"}
+{"Action":"pass","Package":"example.com/app/a","Test":"TestOK"}
+{"Action":"run","Package":"example.com/app/a","Test":"TestTable"}
+{"Action":"fail","Package":"example.com/app/a","Test":"TestTable/empty"}
+{"Action":"fail","Package":"example.com/app/a","Test":"TestTable"}
+{"Action":"fail","Package":"example.com/app/a","Test":"TestOther"}
+{"Action":"fail","Package":"example.com/app/a"}
+# example.com/app/b
+b/b_test.go:3:1: syntax error
+{"Action":"fail","Package":"example.com/app/b","FailedBuild":"example.com/app/b [example.com/app/b.test]"}
+{"Action":"pass","Package":"example.com/app/c"}
+`)
+	got, err := parseTestEvents(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []failedTest{
+		{"example.com/app/a", "TestOther"},
+		{"example.com/app/a", "TestTable/empty"},
+		{Package: "example.com/app/b"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("parseTestEvents mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCheckTests(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":      "module example.com/app\n\ngo 1.22\n",
+		"a/a_test.go": "package a\n\nimport \"testing\"\n\nfunc TestOK(t *testing.T) {}\n\nfunc TestBroken(t *testing.T) { t.Fail() }\n",
+		"b/b_test.go": "package b\n\nimport \"testing\"\n\nfunc TestFlaky(t *testing.T) { t.Fail() }\n",
+		"c/c.go":      "package c\n",
+		"c/c_test.go": "package c\n\nimport \"testing\"\n\nfunc TestOK(t *testing.T) {}\n",
+		"d/d_test.go": "package d\n\nfunc broken(\n",
+	}
+	for name, content := range files {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	config = &AppConfig{GoBinary: "go", TestPackages: []string{"./..."}}
+	ws := &workspace{Dir: dir, Out: &OutputNone{}}
+	t.Cleanup(func() { baselineTestFailures = nil })
+
+	baselineTestFailures = map[string]bool{"example.com/app/b.TestFlaky": true}
+	failed, _, ok := checkTests(ws, nil)
+	if ok {
+		t.Error("checkTests accepted failing tests")
+	}
+	want := []string{"example.com/app/a.TestBroken", "example.com/app/d"}
+	if diff := cmp.Diff(want, failed); diff != "" {
+		t.Errorf("checkTests mismatch (-want +got):\n%s", diff)
+	}
+
+	config.TestPackages = []string{"./b", "./c"}
+	if failed, _, ok := checkTests(ws, nil); !ok || failed != nil {
+		t.Errorf("checkTests = %v, %v; want only baseline failures ignored", failed, ok)
+	}
+
+	config.TestPackages = []string{"./missing"}
+	if failed, _, err := runTestGate(ws); err != nil || !cmp.Equal(failed, []string{"./missing"}) {
+		t.Errorf("runTestGate = %v, %v; want the missing package failed", failed, err)
+	}
+	config.GoBinary = filepath.Join(dir, "no-such-go")
+	if _, _, err := runTestGate(ws); err == nil {
+		t.Error("expected an error for a missing go binary")
+	}
+}
+
+func TestOutputMarkdownPrintTestFailures(t *testing.T) {
+	var buf bytes.Buffer
+	out := NewOutputMarkdown(&buf)
+	out.printTestFailures([]Result{
+		{ModulePath: "example.com/mod", Success: true, VersionBefore: "v1.0.0", VersionAfter: "v1.1.0",
+			TestFailures: []TestFailure{{Version: "v1.2.0", Tests: []string{"example.com/app/a.TestParse", "example.com/app/b"}}}},
+		{ModulePath: "example.com/ok", Success: true, VersionBefore: "v1.0.0", VersionAfter: "v1.1.0"},
+	})
+	expected := "\n### Test failures\n\n" +
+		"* `example.com/mod`\n" +
+		"  * v1.2.0: `example.com/app/a.TestParse`, `example.com/app/b`\n"
+	if diff := cmp.Diff(expected, buf.String()); diff != "" {
+		t.Errorf("printTestFailures mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"go/version"
+	"os"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// pinnedToolchain is the GOTOOLCHAIN value set for every subprocess; empty
+// means the environment is passed through unchanged.
+var pinnedToolchain string
+
+// wantToolchain returns the Go toolchain the run is pinned to: the toolchain
+// directive when present, else the first release of the go directive
+// (-target-go replaces the go directive). It is empty before Go 1.21, which
+// has no toolchain switching.
+func wantToolchain(mod *modfile.File) string {
+	if config.TargetGo != "" {
+		return goRelease(config.TargetGo)
+	}
+	if name := toolchainName(mod); name != "" {
+		return name
+	}
+	if mod.Go == nil {
+		return ""
+	}
+	want := goRelease(mod.Go.Version)
+	if version.Compare(want, "go1.21.0") < 0 {
+		return ""
+	}
+	return want
+}
+
+// pinToolchain verifies the toolchain go env reports before pinning, then sets
+// GOTOOLCHAIN for subprocesses from go.mod (unless -pin-toolchain is off or
+// GOTOOLCHAIN is already set). Running with a toolchain older than the pinned
+// one is an error, a newer one that is not pinned away a warning.
+func pinToolchain(mod *modfile.File) error {
+	want := wantToolchain(mod)
+	if want == "" {
+		return nil
+	}
+	if err := checkLocalToolchain(want); err != nil {
+		return err
+	}
+	if config.PinToolchain && os.Getenv("GOTOOLCHAIN") == "" {
+		pinnedToolchain = want
+	}
+	return nil
+}
+
+// checkLocalToolchain compares the toolchain go env reports with want.
+func checkLocalToolchain(want string) error {
+	buf, err := cmdOutput(config.GoBinary, "env", "GOVERSION")
+	if err != nil {
+		return fmt.Errorf("go env GOVERSION: %w", err)
+	}
+	actual := strings.TrimSpace(string(buf))
+	switch {
+	case !version.IsValid(actual):
+		out.Error("warning: cannot compare toolchain", actual, "with pinned", want)
+	case version.Compare(actual, want) < 0:
+		return fmt.Errorf("refusing to run: toolchain %s is older than %s pinned by %s; install it or set GOTOOLCHAIN=%s", actual, want, config.GoModSrc, want)
+	case version.Compare(actual, want) > 0 && (!config.PinToolchain || os.Getenv("GOTOOLCHAIN") != ""):
+		// Otherwise subprocesses switch to the pinned toolchain.
+		out.Error("warning: running toolchain", actual, "instead of", want, "pinned by", config.GoModSrc, "(GOTOOLCHAIN="+os.Getenv("GOTOOLCHAIN")+")")
+	}
+	return nil
+}
+
+// subprocessEnv returns the environment for subprocesses, with GOTOOLCHAIN pinned when set.
+func subprocessEnv() []string {
+	env := os.Environ()
+	if pinnedToolchain != "" {
+		env = append(env, "GOTOOLCHAIN="+pinnedToolchain)
+	}
+	return env
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/mod/modfile"
+)
+
+func TestWantToolchain(t *testing.T) {
+	tests := []struct {
+		gomod    string
+		targetGo string
+		want     string
+	}{
+		{"module m\n\ngo 1.22\n", "", "go1.22.0"},
+		{"module m\n\ngo 1.22.3\n", "", "go1.22.3"},
+		{"module m\n\ngo 1.22\n\ntoolchain go1.22.5\n", "", "go1.22.5"},
+		{"module m\n\ngo 1.22\n", "1.23", "go1.23.0"},
+		{"module m\n\ngo 1.20\n", "", ""},
+		{"module m\n", "", ""},
+	}
+	for _, tt := range tests {
+		mod, err := modfile.Parse("go.mod", []byte(tt.gomod), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		config = &AppConfig{TargetGo: tt.targetGo}
+		if got := wantToolchain(mod); got != tt.want {
+			t.Errorf("wantToolchain(%q, target %q) = %q, want %q", tt.gomod, tt.targetGo, got, tt.want)
+		}
+	}
+}
+
+func TestPinToolchainOlderLocal(t *testing.T) {
+	// The fake go reports the pinned toolchain when GOTOOLCHAIN is set, like
+	// the go command switching toolchains, and go1.21.0 otherwise.
+	goBinary := filepath.Join(t.TempDir(), "go")
+	script := "#!/bin/sh\necho ${GOTOOLCHAIN:-go1.21.0}\n"
+	if err := os.WriteFile(goBinary, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOTOOLCHAIN", "")
+	mod, err := modfile.Parse("go.mod", []byte("module m\n\ngo 1.22\n"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out = &OutputNone{}
+	config = &AppConfig{GoBinary: goBinary, GoModSrc: "go.mod", PinToolchain: true}
+	defer func() { pinnedToolchain = "" }()
+
+	err = pinToolchain(mod)
+	if err == nil || !strings.Contains(err.Error(), "toolchain go1.21.0 is older than go1.22.0") {
+		t.Errorf("pinToolchain error = %v, want older toolchain error", err)
+	}
+	if pinnedToolchain != "" {
+		t.Errorf("pinnedToolchain = %q after refusing to run", pinnedToolchain)
+	}
+
+	mod, err = modfile.Parse("go.mod", []byte("module m\n\ngo 1.21.0\n"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pinToolchain(mod); err != nil {
+		t.Fatal(err)
+	}
+	if pinnedToolchain != "go1.21.0" {
+		t.Errorf("pinnedToolchain = %q, want go1.21.0", pinnedToolchain)
+	}
+}
+
+func TestPinToolchainNewerLocal(t *testing.T) {
+	goBinary := filepath.Join(t.TempDir(), "go")
+	if err := os.WriteFile(goBinary, []byte("#!/bin/sh\necho go1.23.0\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	mod, err := modfile.Parse("go.mod", []byte("module m\n\ngo 1.22\n"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { pinnedToolchain = "" }()
+
+	tests := []struct {
+		pin         bool
+		gotoolchain string
+		warn        bool
+	}{
+		{true, "", false},
+		{false, "", true},
+		{true, "go1.23.0", true},
+	}
+	for _, tt := range tests {
+		t.Setenv("GOTOOLCHAIN", tt.gotoolchain)
+		var buf bytes.Buffer
+		out = (&OutputConsole{}).Buffered(&buf)
+		config = &AppConfig{GoBinary: goBinary, GoModSrc: "go.mod", PinToolchain: tt.pin}
+		pinnedToolchain = ""
+		if err := pinToolchain(mod); err != nil {
+			t.Fatal(err)
+		}
+		if warned := strings.Contains(buf.String(), "warning: running toolchain go1.23.0"); warned != tt.warn {
+			t.Errorf("pin %v, GOTOOLCHAIN %q: warned = %v, output %q", tt.pin, tt.gotoolchain, warned, buf.String())
+		}
+	}
+}
@@ -0,0 +1,352 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// osvEntry is the subset of an OSV advisory (https://ossf.github.io/osv-schema/)
+// gobump needs, as published by vuln.go.dev.
+type osvEntry struct {
+	ID        string        `json:"id"`
+	Aliases   []string      `json:"aliases"`
+	Summary   string        `json:"summary"`
+	Withdrawn string        `json:"withdrawn"`
+	Affected  []osvAffected `json:"affected"`
+}
+
+type osvAffected struct {
+	Package struct {
+		Name      string `json:"name"`
+		Ecosystem string `json:"ecosystem"`
+	} `json:"package"`
+	Ranges            []osvRange `json:"ranges"`
+	EcosystemSpecific struct {
+		Imports []osvImport `json:"imports"`
+	} `json:"ecosystem_specific"`
+}
+
+// osvImport lists the vulnerable symbols of a package, e.g. Template.Execute;
+// no symbols means the whole package is affected.
+type osvImport struct {
+	Path    string   `json:"path"`
+	Symbols []string `json:"symbols"`
+}
+
+type osvRange struct {
+	Type   string `json:"type"`
+	Events []struct {
+		Introduced   string `json:"introduced"`
+		Fixed        string `json:"fixed"`
+		LastAffected string `json:"last_affected"`
+	} `json:"events"`
+}
+
+// osvVersion converts an OSV SEMVER version (no v prefix, "0" for the
+// beginning of time) to a Go module version.
+func osvVersion(v string) string {
+	if v == "0" {
+		return ""
+	}
+	return "v" + v
+}
+
+// affects reports whether the SEMVER ranges of a include version.
+func (a osvAffected) affects(version string) bool {
+	for _, r := range a.Ranges {
+		if r.Type != "SEMVER" {
+			continue
+		}
+		affected := false
+		for _, e := range r.Events {
+			switch {
+			case e.Introduced != "":
+				if intro := osvVersion(e.Introduced); intro == "" || semver.Compare(version, intro) >= 0 {
+					affected = true
+				}
+			case e.Fixed != "":
+				if semver.Compare(version, osvVersion(e.Fixed)) >= 0 {
+					affected = false
+				}
+			case e.LastAffected != "":
+				if semver.Compare(version, osvVersion(e.LastAffected)) > 0 {
+					affected = false
+				}
+			}
+		}
+		if affected {
+			return true
+		}
+	}
+	return false
+}
+
+// Name returns the ID with its aliases, e.g. GO-2023-1234 (CVE-2023-1234).
+func (e *osvEntry) Name() string {
+	if len(e.Aliases) == 0 {
+		return e.ID
+	}
+	return e.ID + " (" + strings.Join(e.Aliases, ", ") + ")"
+}
+
+// vulnDatabase indexes OSV advisories by module path.
+type vulnDatabase struct {
+	byModule map[string][]*osvEntry
+	// reachable records per advisory ID whether its symbols are called by
+	// the main module, nil unless -reachability analysed it.
+	reachable map[string]bool
+}
+
+// vulnDB is the database loaded with -vulndb, nil when none was given.
+var vulnDB *vulnDatabase
+
+// loadVulnDatabase reads every advisory of a vuln.go.dev style database from
+// a directory or a zip file. Advisories are the .json files in ID/ or, when
+// that does not exist, anywhere in the tree.
+func loadVulnDatabase(file string) (*vulnDatabase, error) {
+	st, err := os.Stat(file)
+	if err != nil {
+		return nil, fmt.Errorf("vulnerability database: %w", err)
+	}
+	var fsys fs.FS
+	if st.IsDir() {
+		fsys = os.DirFS(file)
+	} else {
+		zr, err := zip.OpenReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("vulnerability database: %w", err)
+		}
+		defer zr.Close()
+		fsys = zr
+	}
+
+	root := "."
+	if st, err := fs.Stat(fsys, "ID"); err == nil && st.IsDir() {
+		root = "ID"
+	}
+	db := &vulnDatabase{byModule: map[string][]*osvEntry{}}
+	err = fs.WalkDir(fsys, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || path.Ext(p) != ".json" {
+			return nil
+		}
+		f, err := fsys.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return db.add(p, f)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("vulnerability database: %w", err)
+	}
+	return db, nil
+}
+
+// add decodes one advisory; files that are not advisories (indexes) are skipped.
+func (db *vulnDatabase) add(name string, r io.Reader) error {
+	var entry osvEntry
+	if err := json.NewDecoder(r).Decode(&entry); err != nil {
+		if path.Dir(name) == "ID" {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		return nil
+	}
+	if entry.ID == "" || entry.Withdrawn != "" {
+		return nil
+	}
+	for _, a := range entry.Affected {
+		modulePath := a.Package.Name
+		if modulePath == "" || modulePath == "stdlib" || modulePath == "toolchain" {
+			continue
+		}
+		// An advisory can list the same module more than once.
+		if entries := db.byModule[modulePath]; len(entries) == 0 || entries[len(entries)-1] != &entry {
+			db.byModule[modulePath] = append(entries, &entry)
+		}
+	}
+	return nil
+}
+
+// advisories returns the advisories affecting modulePath at version, sorted by ID.
+func (db *vulnDatabase) advisories(modulePath, version string) []*osvEntry {
+	if db == nil {
+		return nil
+	}
+	var result []*osvEntry
+	for _, e := range db.byModule[modulePath] {
+		if slices.ContainsFunc(e.Affected, func(a osvAffected) bool {
+			return a.Package.Name == modulePath && a.affects(version)
+		}) {
+			result = append(result, e)
+		}
+	}
+	slices.SortFunc(result, func(a, b *osvEntry) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	return result
+}
+
+const (
+	reachable   = "reachable"
+	unreachable = "unreachable"
+)
+
+// Advisory is an advisory from -vulndb reported on a Result.
+type Advisory struct {
+	Name         string // ID with aliases
+	Reachability string // reachable, unreachable, or empty without -reachability
+	FixedIn      string // open advisories: smallest newer version not affected, empty when there is none
+	FixedInGo    string // open advisories: go directive of FixedIn when the pinned Go cannot build it
+}
+
+// fixUnknown is set as FixedIn, or FixedInGo, of open advisories when the
+// proxy lookup of their fix failed.
+const fixUnknown = "unknown"
+
+func (a Advisory) String() string {
+	s := a.Name
+	if a.Reachability != "" {
+		s += " [" + a.Reachability + "]"
+	}
+	return s
+}
+
+// advisory returns the Advisory of e with its reachability.
+func (db *vulnDatabase) advisory(e *osvEntry) Advisory {
+	a := Advisory{Name: e.Name()}
+	if r, ok := db.reachable[e.ID]; ok {
+		a.Reachability = unreachable
+		if r {
+			a.Reachability = reachable
+		}
+	}
+	return a
+}
+
+// advisoriesFixed returns the advisories affecting before but not after, or
+// nil without a database.
+func advisoriesFixed(modulePath, before, after string) []Advisory {
+	if vulnDB == nil || before == after {
+		return nil
+	}
+	var fixed []Advisory
+	for _, e := range vulnDB.advisories(modulePath, before) {
+		if !slices.Contains(vulnDB.advisories(modulePath, after), e) {
+			fixed = append(fixed, vulnDB.advisory(e))
+		}
+	}
+	return fixed
+}
+
+// advisoryList formats advisories as a comma-separated list.
+func advisoryList(advisories []Advisory, describe func(Advisory) string) string {
+	s := make([]string, len(advisories))
+	for i, a := range advisories {
+		s[i] = describe(a)
+	}
+	return strings.Join(s, ", ")
+}
+
+// securityCandidates returns the versions not affected by any advisory -security
+// acts on, in ascending order, so the smallest fixing version is tried first.
+func securityCandidates(modulePath string, versions []module.Version) []module.Version {
+	var result []module.Version
+	for _, v := range versions {
+		if len(securityAdvisories(modulePath, v.Version)) == 0 {
+			result = append(result, v)
+		}
+	}
+	slices.SortFunc(result, func(a, b module.Version) int {
+		return semver.Compare(a.Version, b.Version)
+	})
+	return result
+}
+
+// openString formats an open advisory together with its fix.
+func (a Advisory) openString() string {
+	switch {
+	case a.FixedIn == "":
+		return a.String() + " (no fix)"
+	case a.FixedIn == fixUnknown:
+		return a.String() + " (fix unknown)"
+	case a.FixedInGo == fixUnknown:
+		return fmt.Sprintf("%s (fixed in %s, go requirement unknown)", a, a.FixedIn)
+	case a.FixedInGo != "":
+		return fmt.Sprintf("%s (fixed in %s, requires go %s)", a, a.FixedIn, a.FixedInGo)
+	}
+	return fmt.Sprintf("%s (fixed in %s)", a, a.FixedIn)
+}
+
+// openAdvisories returns the advisories affecting modulePath at version, each
+// with the smallest newer version fixing it and whether that needs a Go newer
+// than pinned. When the proxy lookups fail, it returns the advisories with
+// the fix marked fixUnknown together with the error.
+func openAdvisories(proxy *GoProxy, modulePath, version, pinned string) ([]Advisory, error) {
+	entries := vulnDB.advisories(modulePath, version)
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	versions, err := proxy.FetchVersions(modulePath, version)
+	if err != nil {
+		var open []Advisory
+		for _, e := range entries {
+			a := vulnDB.advisory(e)
+			a.FixedIn = fixUnknown
+			open = append(open, a)
+		}
+		return open, err
+	}
+	slices.SortFunc(versions, func(a, b module.Version) int {
+		return semver.Compare(a.Version, b.Version)
+	})
+	var open []Advisory
+	var modErr error
+	for _, e := range entries {
+		a := vulnDB.advisory(e)
+		for _, v := range versions {
+			if slices.Contains(vulnDB.advisories(modulePath, v.Version), e) {
+				continue
+			}
+			a.FixedIn = v.Version
+			mod, err := proxy.FetchModFile(modulePath, v.Version)
+			if err != nil {
+				a.FixedInGo = fixUnknown
+				modErr = err
+			} else if !goCompatible(mod, pinned) {
+				a.FixedInGo = mod.Go.Version
+			}
+			break
+		}
+		open = append(open, a)
+	}
+	return open, modErr
+}
+
+// markOpenAdvisories records on each result the advisories still affecting
+// VersionAfter. Failed fix lookups are reported; the advisories are kept.
+func markOpenAdvisories(proxy *GoProxy, results []Result, pinned string) {
+	for i, r := range results {
+		version := r.VersionAfter
+		if version == "" {
+			version = r.VersionBefore
+		}
+		open, err := openAdvisories(proxy, r.ModulePath, version, pinned)
+		if err != nil {
+			out.Error("failed to look up fixes of open advisories of", r.ModulePath+":", err.Error())
+		}
+		results[i].OpenAdvisories = open
+	}
+}
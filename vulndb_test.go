@@ -0,0 +1,231 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/mod/module"
+)
+
+var testAdvisories = map[string]string{
+	"ID/GO-2024-0001.json": `{"id":"GO-2024-0001","aliases":["CVE-2024-1111"],"affected":[{"package":{"name":"example.com/vuln","ecosystem":"Go"},"ranges":[{"type":"SEMVER","events":[{"introduced":"0"},{"fixed":"1.2.0"},{"introduced":"1.5.0"},{"fixed":"1.5.2"}]}]}]}`,
+	"ID/GO-2024-0002.json": `{"id":"GO-2024-0002","affected":[{"package":{"name":"example.com/vuln","ecosystem":"Go"},"ranges":[{"type":"SEMVER","events":[{"introduced":"1.1.0"},{"last_affected":"1.3.0"}]}]}]}`,
+	"ID/GO-2024-0003.json": `{"id":"GO-2024-0003","withdrawn":"2024-02-01T00:00:00Z","affected":[{"package":{"name":"example.com/vuln","ecosystem":"Go"},"ranges":[{"type":"SEMVER","events":[{"introduced":"0"}]}]}]}`,
+	"index/modules.json":   `[{"path":"example.com/vuln"}]`,
+}
+
+func writeTestVulnDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range testAdvisories {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func writeTestVulnZip(t *testing.T) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "vulndb.zip")
+	f, err := os.Create(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for name, content := range testAdvisories {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestVulnDatabase(t *testing.T) {
+	for name, file := range map[string]string{"dir": writeTestVulnDir(t), "zip": writeTestVulnZip(t)} {
+		t.Run(name, func(t *testing.T) {
+			db, err := loadVulnDatabase(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tests := []struct {
+				version string
+				want    []string
+			}{
+				{"v1.0.0", []string{"GO-2024-0001"}},
+				{"v1.1.0", []string{"GO-2024-0001", "GO-2024-0002"}},
+				{"v1.2.0", []string{"GO-2024-0002"}},
+				{"v1.3.1", nil},
+				{"v1.5.1", []string{"GO-2024-0001"}},
+				{"v1.5.2", nil},
+			}
+			for _, tt := range tests {
+				var got []string
+				for _, e := range db.advisories("example.com/vuln", tt.version) {
+					got = append(got, e.ID)
+				}
+				if !slices.Equal(got, tt.want) {
+					t.Errorf("advisories(%s) = %v, want %v", tt.version, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSecurityCandidates(t *testing.T) {
+	db, err := loadVulnDatabase(writeTestVulnDir(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	vulnDB = db
+	defer func() { vulnDB = nil }()
+	config = &AppConfig{}
+
+	versions := []module.Version{{Version: "v1.6.0"}, {Version: "v1.5.1"}, {Version: "v1.4.0"}, {Version: "v1.3.0"}, {Version: "v1.2.0"}}
+	var got []string
+	for _, v := range securityCandidates("example.com/vuln", versions) {
+		got = append(got, v.Version)
+	}
+	if want := []string{"v1.4.0", "v1.6.0"}; !slices.Equal(got, want) {
+		t.Errorf("securityCandidates = %v, want %v", got, want)
+	}
+	if got := newestVersion(securityCandidates("example.com/vuln", versions)); got != "v1.6.0" {
+		t.Errorf("newest security candidate = %s, want v1.6.0", got)
+	}
+
+	fixed := advisoriesFixed("example.com/vuln", "v1.1.0", "v1.4.0")
+	if diff := cmp.Diff([]Advisory{{Name: "GO-2024-0001 (CVE-2024-1111)"}, {Name: "GO-2024-0002"}}, fixed); diff != "" {
+		t.Errorf("advisoriesFixed mismatch (-want +got):\n%s", diff)
+	}
+	if fixed := advisoriesFixed("example.com/vuln", "v1.4.0", "v1.5.1"); fixed != nil {
+		t.Errorf("advisoriesFixed for a newly affected version = %v", fixed)
+	}
+}
+
+func TestOpenAdvisories(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/example.com/vuln/@v/list":
+			fmt.Fprintln(w, "v1.1.0\nv1.4.0\nv1.5.2")
+		case "/example.com/vuln/@v/v1.4.0.mod":
+			fmt.Fprintln(w, "module example.com/vuln\n\ngo 1.23")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	db, err := loadVulnDatabase(writeTestVulnDir(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	vulnDB = db
+	defer func() { vulnDB = nil }()
+	config = &AppConfig{}
+	out = &OutputNone{}
+
+	open, err := openAdvisories(NewGoProxy(server.URL), "example.com/vuln", "v1.1.0", "go1.22.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Advisory{
+		{Name: "GO-2024-0001 (CVE-2024-1111)", FixedIn: "v1.4.0", FixedInGo: "1.23"},
+		{Name: "GO-2024-0002", FixedIn: "v1.4.0", FixedInGo: "1.23"},
+	}
+	if diff := cmp.Diff(want, open); diff != "" {
+		t.Errorf("openAdvisories mismatch (-want +got):\n%s", diff)
+	}
+	if got := want[0].openString(); got != "GO-2024-0001 (CVE-2024-1111) (fixed in v1.4.0, requires go 1.23)" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestMarkOpenAdvisoriesLookupFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/example.com/vuln/@v/list" {
+			fmt.Fprintln(w, "v1.1.0\nv1.4.0")
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	db, err := loadVulnDatabase(writeTestVulnDir(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	vulnDB = db
+	defer func() { vulnDB = nil }()
+	config = &AppConfig{}
+	out = &OutputNone{}
+
+	// The .mod file of the fixing version is missing.
+	results := []Result{{ModulePath: "example.com/vuln", VersionBefore: "v1.1.0", VersionAfter: "v1.1.0"}}
+	markOpenAdvisories(NewGoProxy(server.URL), results, "go1.22.0")
+	want := []Advisory{
+		{Name: "GO-2024-0001 (CVE-2024-1111)", FixedIn: "v1.4.0", FixedInGo: fixUnknown},
+		{Name: "GO-2024-0002", FixedIn: "v1.4.0", FixedInGo: fixUnknown},
+	}
+	if diff := cmp.Diff(want, results[0].OpenAdvisories); diff != "" {
+		t.Errorf("OpenAdvisories without .mod mismatch (-want +got):\n%s", diff)
+	}
+	if got := want[0].openString(); got != "GO-2024-0001 (CVE-2024-1111) (fixed in v1.4.0, go requirement unknown)" {
+		t.Errorf("openString() = %q", got)
+	}
+
+	// The version list is missing.
+	results = []Result{{ModulePath: "example.com/vuln", VersionBefore: "v1.1.0", VersionAfter: "v1.1.0"}}
+	markOpenAdvisories(NewGoProxy(server.URL+"/missing"), results, "go1.22.0")
+	want = []Advisory{
+		{Name: "GO-2024-0001 (CVE-2024-1111)", FixedIn: fixUnknown},
+		{Name: "GO-2024-0002", FixedIn: fixUnknown},
+	}
+	if diff := cmp.Diff(want, results[0].OpenAdvisories); diff != "" {
+		t.Errorf("OpenAdvisories without versions mismatch (-want +got):\n%s", diff)
+	}
+	if got := want[1].openString(); got != "GO-2024-0002 (fix unknown)" {
+		t.Errorf("openString() = %q", got)
+	}
+}
+
+func TestOutputMarkdownAdvisories(t *testing.T) {
+	var buf bytes.Buffer
+	out := NewOutputMarkdown(&buf)
+
+	results := []Result{
+		{ModulePath: "example.com/fixed", Success: true, VersionBefore: "v1.0.0", VersionAfter: "v1.2.0", Fixes: []Advisory{{Name: "GO-2024-0001 (CVE-2024-1111)", Reachability: reachable}}},
+		{ModulePath: "example.com/open", VersionBefore: "v1.1.0", VersionAfter: "v1.1.0", OpenAdvisories: []Advisory{{Name: "GO-2024-0002", Reachability: unreachable, FixedIn: "v1.4.0", FixedInGo: "1.23"}}},
+	}
+	out.printSecurityFixes(results)
+	out.printOpenAdvisories(results)
+
+	expected := "\n### Security fixes\n\n" +
+		"* `example.com/fixed` v1.2.0: GO-2024-0001 (CVE-2024-1111) [reachable]\n" +
+		"\n### Still vulnerable\n\n" +
+		"* `example.com/open` v1.1.0: GO-2024-0002 [unreachable] (fixed in v1.4.0, requires go 1.23)\n"
+	if diff := cmp.Diff(expected, buf.String()); diff != "" {
+		t.Errorf("advisory sections mismatch (-want +got):\n%s", diff)
+	}
+}
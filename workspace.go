@@ -0,0 +1,212 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/mod/modfile"
+)
+
+// worktreeMu serialises git worktree add/remove, which lock the shared repository metadata.
+var worktreeMu sync.Mutex
+
+// workspace is a directory in which candidate upgrades are evaluated: the main
+// tree for sequential runs, or an isolated checkout for -parallel workers.
+type workspace struct {
+	Dir      string // working directory for subprocesses; empty means the current directory
+	GoModSrc string
+	GoModDst string
+	Out      Output
+	remove   func() error
+}
+
+// mainWorkspace returns the workspace for the tree gobump was started in.
+func mainWorkspace() *workspace {
+	return &workspace{
+		GoModSrc: config.GoModSrc,
+		GoModDst: config.GoModDst,
+		Out:      out,
+	}
+}
+
+func (ws *workspace) cmd(name string, args ...string) error {
+	return cmdIn(ws.Out, ws.Dir, name, args...)
+}
+
+func (ws *workspace) cmds(str string) error {
+	return cmdsIn(ws.Out, ws.Dir, str)
+}
+
+// cmdsOutput is cmds returning the combined output, see cmdsOutputIn.
+func (ws *workspace) cmdsOutput(str string) ([]byte, error) {
+	return cmdsOutputIn(ws.Out, ws.Dir, str)
+}
+
+// output runs a subprocess in the workspace and returns its stdout.
+func (ws *workspace) output(name string, args ...string) ([]byte, error) {
+	return cmdOutputIn(ws.Dir, name, args...)
+}
+
+// Remove deletes an isolated workspace; it is a no-op for the main workspace.
+func (ws *workspace) Remove() error {
+	if ws.remove == nil {
+		return nil
+	}
+	return ws.remove()
+}
+
+// newIsolatedWorkspace creates a private checkout of the current module with
+// mod as its go.mod: a detached git worktree when useGit is set, else a copy
+// of the current directory.
+func newIsolatedWorkspace(mod *modfile.File, o Output, useGit bool) (*workspace, error) {
+	tmp, err := os.MkdirTemp("", "gobump-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create workspace: %w", err)
+	}
+	ws := &workspace{Out: o}
+	var root string // of the checkout or copy
+
+	if useGit {
+		top, err := exec.Command("git", "rev-parse", "--show-toplevel").Output()
+		if err != nil {
+			os.RemoveAll(tmp)
+			return nil, fmt.Errorf("git rev-parse --show-toplevel: %w", err)
+		}
+		wd, err := os.Getwd()
+		if err != nil {
+			os.RemoveAll(tmp)
+			return nil, err
+		}
+		rel, err := filepath.Rel(strings.TrimSpace(string(top)), wd)
+		if err != nil {
+			os.RemoveAll(tmp)
+			return nil, err
+		}
+		checkout := filepath.Join(tmp, "checkout")
+		root = checkout
+		worktreeMu.Lock()
+		err = gitRun("worktree", "add", "--detach", checkout, "HEAD")
+		worktreeMu.Unlock()
+		if err != nil {
+			os.RemoveAll(tmp)
+			return nil, fmt.Errorf("git worktree add: %w", err)
+		}
+		ws.Dir = filepath.Join(checkout, rel)
+		ws.remove = func() error {
+			defer os.RemoveAll(tmp)
+			worktreeMu.Lock()
+			defer worktreeMu.Unlock()
+			if err := gitRun("worktree", "remove", "--force", checkout); err != nil {
+				return fmt.Errorf("git worktree remove: %w", err)
+			}
+			return nil
+		}
+	} else {
+		if err := copyTree(".", tmp); err != nil {
+			os.RemoveAll(tmp)
+			return nil, fmt.Errorf("failed to copy module: %w", err)
+		}
+		ws.Dir = tmp
+		root = tmp
+		ws.remove = func() error {
+			return os.RemoveAll(tmp)
+		}
+	}
+
+	for _, p := range []struct {
+		dst  *string
+		path string
+	}{{&ws.GoModSrc, config.GoModSrc}, {&ws.GoModDst, config.GoModDst}} {
+		if *p.dst, err = workspacePath(root, ws.Dir, p.path); err != nil {
+			ws.Remove()
+			return nil, err
+		}
+	}
+	// go commands read the starting requirements from the source go.mod
+	for _, file := range []string{ws.GoModSrc, ws.GoModDst} {
+		if err := saveMod(file, mod); err != nil {
+			ws.Remove()
+			return nil, err
+		}
+	}
+	return ws, nil
+}
+
+// workspacePath maps a path of the current tree, such as -src-go-mod, into a
+// workspace whose directory dir is the current directory in a checkout at root.
+func workspacePath(root, dir, path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(wd, abs)
+	if err != nil {
+		return "", err
+	}
+	mapped := filepath.Join(dir, rel)
+	if r, err := filepath.Rel(root, mapped); err != nil || r == ".." || strings.HasPrefix(r, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside the workspace checkout, -parallel needs it within the repository (or the current directory with -no-git)", path)
+	}
+	return mapped, nil
+}
+
+// copyTree copies the regular files, directories and symlinks under src to dst, skipping .git.
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		switch {
+		case d.IsDir():
+			if d.Name() == ".git" && rel != "." {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(target, 0755)
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case d.Type().IsRegular():
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			return copyFile(path, target, info.Mode().Perm())
+		}
+		return nil
+	})
+}
+
+func copyFile(src, dst string, perm fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	f, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, in); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewIsolatedWorkspaceCopy(t *testing.T) {
+	tmp := t.TempDir()
+	oldWd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(oldWd) })
+	if err := os.Chdir(tmp); err != nil {
+		t.Fatal(err)
+	}
+
+	config = &AppConfig{GoModSrc: "go.mod", GoModDst: "go.mod"}
+	if err := os.WriteFile("go.mod", []byte("module example.com/m\n\ngo 1.22\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join("pkg", ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join("pkg", "a.go"), []byte("package pkg\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(".git", 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	mod, err := parseMod("go.mod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mod.AddNewRequire("example.com/dep", "v1.0.0", false)
+
+	var buf bytes.Buffer
+	ws, err := newIsolatedWorkspace(mod, (&OutputConsole{}).Buffered(&buf), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(ws.Dir, "pkg", "a.go")); err != nil {
+		t.Errorf("expected copied file: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(ws.Dir, ".git")); !os.IsNotExist(err) {
+		t.Errorf("expected .git to be skipped, got %v", err)
+	}
+	wsMod, err := parseMod(ws.GoModSrc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := requiredVersion(wsMod, "example.com/dep", ""); got != "v1.0.0" {
+		t.Errorf("workspace go.mod require = %q", got)
+	}
+
+	if err := ws.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(ws.Dir); !os.IsNotExist(err) {
+		t.Errorf("expected workspace to be removed, got %v", err)
+	}
+}
+
+func TestNewIsolatedWorkspaceGoModPath(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "repo")
+	writeFiles(t, dir, map[string]string{
+		"go.mod":     "module example.com/m\n\ngo 1.22\n",
+		"sub/go.mod": "module example.com/m/sub\n\ngo 1.22\n",
+	})
+	t.Chdir(dir)
+	mod, err := parseMod("sub/go.mod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mod.AddNewRequire("example.com/dep", "v1.0.0", false)
+
+	config = &AppConfig{GoModSrc: "sub/go.mod", GoModDst: filepath.Join(dir, "sub", "go.mod")}
+	ws, err := newIsolatedWorkspace(mod, &OutputNone{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Remove()
+	want := filepath.Join(ws.Dir, "sub", "go.mod")
+	if ws.GoModSrc != want || ws.GoModDst != want {
+		t.Errorf("workspace go.mod = %s, %s; want %s", ws.GoModSrc, ws.GoModDst, want)
+	}
+	wsMod, err := parseMod(ws.GoModSrc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := requiredVersion(wsMod, "example.com/dep", ""); got != "v1.0.0" {
+		t.Errorf("workspace go.mod require = %q", got)
+	}
+
+	writeFiles(t, root, map[string]string{"outside/go.mod": "module example.com/o\n"})
+	config = &AppConfig{GoModSrc: "../outside/go.mod", GoModDst: "go.mod"}
+	if ws, err := newIsolatedWorkspace(mod, &OutputNone{}, false); err == nil {
+		ws.Remove()
+		t.Error("expected an error for a go.mod outside the copied tree")
+	}
+}
+
+func TestOutputConsoleBuffered(t *testing.T) {
+	var buf bytes.Buffer
+	o := (&OutputConsole{}).Buffered(&buf)
+	o.BeginPreformatted("go get example.com/dep")
+	o.Println("line")
+	o.Error("failure")
+	o.EndPreformatted()
+
+	if want := "go get example.com/dep\nline\nfailure\n"; buf.String() != want {
+		t.Errorf("buffered output = %q, want %q", buf.String(), want)
+	}
+}